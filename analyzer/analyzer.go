@@ -0,0 +1,214 @@
+// Package analyzer tgenが生成するテストケースのうち、既存のテストにないものを報告するAnalyzer
+// テストを実行せずに、エラーの分岐などのテストケースが揃っているかをレビューで確認するために利用する
+package analyzer
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kazdevl/tgen/internal"
+	"golang.org/x/tools/go/analysis"
+)
+
+// Analyzer テスト対象の関数ごとに、tgenが生成するテストケースの名前と既存の_test.goのテストケースの名前を比較し、
+// 対応するテストケースがない分岐を報告する
+var Analyzer = &analysis.Analyzer{
+	Name: "tgen",
+	Doc:  "report branches that have no corresponding test case in the existing _test.go",
+	Run:  run,
+}
+
+// lang テストケース名とメッセージの言語(ja, en)
+// 既存のテストケースの名前は、この言語で生成されたものとして比較する
+var lang string
+
+func init() {
+	Analyzer.Flags.StringVar(&lang, "lang", internal.LangJa, "language of test case names and messages (ja, en)")
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	testFiles := map[string]*ast.File{}
+	for _, f := range pass.Files {
+		filename := pass.Fset.Position(f.Pos()).Filename
+		if strings.HasSuffix(filename, "_test.go") {
+			testFiles[filename] = f
+		}
+	}
+	for _, f := range pass.Files {
+		filename := pass.Fset.Position(f.Pos()).Filename
+		if strings.HasSuffix(filename, "_test.go") || isGenerated(f) {
+			continue
+		}
+		if err := checkFile(pass, f, testFiles); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// checkFile テスト対象のファイルの関数ごとに、対応するテストケースがない分岐を報告する
+// tgenで解析できないファイル(複数の構造体のメソッドを含むなど)は対象外にする
+func checkFile(pass *analysis.Pass, f *ast.File, testFiles map[string]*ast.File) error {
+	testFile, err := internal.GetAnalysisResult(f, pass.Fset, pass.Pkg, pass.TypesInfo, nil)
+	if err != nil {
+		return nil
+	}
+	params := internal.CreateTemplateParams(testFile, lang)
+	if len(params.TargetMethodTesCasesMap) == 0 {
+		return nil
+	}
+	testPath := strings.TrimSuffix(pass.Fset.Position(f.Pos()).Filename, ".go") + "_test.go"
+	caseNames, err := readTestCaseNames(testPath, testFiles)
+	if err != nil {
+		return err
+	}
+
+	for _, decl := range f.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		testCases, ok := params.TargetMethodTesCasesMap[funcDecl.Name.Name]
+		if !ok {
+			continue
+		}
+		testName := testFuncName(funcDecl)
+		if instantiation, ok := params.InstantiationMap[funcDecl.Name.Name]; ok && instantiation.TestName != "" {
+			testName = instantiation.TestName
+		}
+		names, ok := caseNames[testName]
+		if !ok {
+			pass.Reportf(funcDecl.Name.Pos(), "%s", internal.Localize(lang, "vet.missing_test", funcDecl.Name.Name, testName))
+			continue
+		}
+		ifPositions := ifStmtPositions(pass.Fset, funcDecl)
+		for _, testCase := range testCases {
+			if names[testCase.Name] {
+				continue
+			}
+			pos, ok := ifPositions[testCase.Line]
+			if !ok || testCase.IsSuccessPattern || testCase.IsCancelPattern {
+				pos = funcDecl.Name.Pos()
+			}
+			pass.Reportf(pos, "%s", internal.Localize(lang, "vet.missing_case", funcDecl.Name.Name, testName, testCase.Name))
+		}
+	}
+	return nil
+}
+
+// ifStmtPositions 関数内のif文の行数と、その位置
+// テストケースは分岐点となるif文の行数のみを持つため、報告する位置を求めるのに利用する
+func ifStmtPositions(fset *token.FileSet, src *ast.FuncDecl) map[int]token.Pos {
+	positions := map[int]token.Pos{}
+	ast.Inspect(src, func(node ast.Node) bool {
+		if ifStmt, ok := node.(*ast.IfStmt); ok {
+			positions[fset.Position(ifStmt.Pos()).Line] = ifStmt.Pos()
+		}
+		return true
+	})
+	return positions
+}
+
+// readTestCaseNames テストファイルのテスト関数ごとの、テストケースの名前(name: "..."の値)の一覧を返す
+// パッケージのテストとして読み込まれていない場合は、ディスクから読み込む(テストファイルがない場合は空)
+func readTestCaseNames(testPath string, testFiles map[string]*ast.File) (map[string]map[string]bool, error) {
+	f, ok := testFiles[testPath]
+	if !ok {
+		var err error
+		f, err = parser.ParseFile(token.NewFileSet(), testPath, nil, parser.SkipObjectResolution)
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]map[string]bool{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	caseNames := map[string]map[string]bool{}
+	for _, decl := range f.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv != nil || funcDecl.Body == nil {
+			continue
+		}
+		names := map[string]bool{}
+		ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+			kv, ok := node.(*ast.KeyValueExpr)
+			if !ok {
+				return true
+			}
+			key, ok := kv.Key.(*ast.Ident)
+			if !ok || key.Name != "name" {
+				return true
+			}
+			if lit, ok := kv.Value.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				if name, err := strconv.Unquote(lit.Value); err == nil {
+					names[name] = true
+				}
+			}
+			return true
+		})
+		caseNames[funcDecl.Name.Name] = names
+	}
+	return caseNames, nil
+}
+
+// testFuncName gotestsが生成するテスト関数の名前
+// メソッドの場合は「Test + レシーバーの型名 + _ + メソッド名」、非公開の名前の場合は「_」で区切る
+func testFuncName(src *ast.FuncDecl) string {
+	name := src.Name.Name
+	if strings.HasPrefix(name, "Test") {
+		return name
+	}
+	if src.Recv != nil && len(src.Recv.List) != 0 {
+		recvName := recvTypeName(src.Recv.List[0].Type)
+		if isLower(recvName) {
+			recvName = "_" + recvName
+		}
+		return "Test" + recvName + "_" + name
+	}
+	if isLower(name) {
+		return "Test_" + name
+	}
+	return "Test" + name
+}
+
+// recvTypeName レシーバーの型名(ポインタの*と型パラメータは含まない)
+func recvTypeName(src ast.Expr) string {
+	switch expr := src.(type) {
+	case *ast.StarExpr:
+		return recvTypeName(expr.X)
+	case *ast.IndexExpr:
+		return recvTypeName(expr.X)
+	case *ast.IndexListExpr:
+		return recvTypeName(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	}
+	return ""
+}
+
+func isLower(src string) bool {
+	r, _ := utf8.DecodeRuneInString(src)
+	return unicode.IsLower(r)
+}
+
+// isGenerated 自動生成されたファイル(mockなど)か否か
+func isGenerated(src *ast.File) bool {
+	for _, group := range src.Comments {
+		if group.Pos() > src.Package {
+			return false
+		}
+		for _, comment := range group.List {
+			if strings.HasPrefix(comment.Text, "// Code generated ") && strings.HasSuffix(comment.Text, " DO NOT EDIT.") {
+				return true
+			}
+		}
+	}
+	return false
+}
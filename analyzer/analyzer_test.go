@@ -0,0 +1,16 @@
+package analyzer
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+// TestAnalyzer 既存のテストにないテストケースとテスト関数を報告することを確認する
+// loadedは_test.goがパッケージのテストとして読み込まれる場合、fromdiskは外部テストパッケージのためディスクから読み込む場合
+func TestAnalyzer(t *testing.T) {
+	if err := Analyzer.Flags.Set("lang", "en"); err != nil {
+		t.Fatal(err)
+	}
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "loaded", "fromdisk")
+}
@@ -0,0 +1,17 @@
+package fromdisk
+
+type Repository interface {
+	Find(id int) (string, error)
+}
+
+type Service struct {
+	Repo Repository
+}
+
+func (s *Service) Get(id int) (string, error) { // want `test of Get \(TestService_Get\) has no case "success"`
+	name, err := s.Repo.Find(id)
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
@@ -0,0 +1,14 @@
+package fromdisk_test
+
+import "testing"
+
+func TestService_Get(t *testing.T) {
+	tests := []struct {
+		name string
+	}{
+		{name: "Find returns error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {})
+	}
+}
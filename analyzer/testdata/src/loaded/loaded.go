@@ -0,0 +1,27 @@
+package loaded
+
+import "errors"
+
+type Repository interface {
+	Find(id int) (string, error)
+}
+
+type Service struct {
+	Repo Repository
+}
+
+func (s *Service) Get(id int) (string, error) {
+	name, err := s.Repo.Find(id)
+	if err != nil {
+		return "", err
+	}
+	if len(name) == 0 { // want `test of Get \(TestService_Get\) has no case "len\(name\) == 0"`
+		return "", errors.New("empty")
+	}
+	return name, nil
+}
+
+func (s *Service) Put(id int) error { // want `Put has no test \(TestService_Put\)`
+	_, err := s.Repo.Find(id)
+	return err
+}
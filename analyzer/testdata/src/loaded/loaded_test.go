@@ -0,0 +1,15 @@
+package loaded
+
+import "testing"
+
+func TestService_Get(t *testing.T) {
+	tests := []struct {
+		name string
+	}{
+		{name: "Find returns error"},
+		{name: "success"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {})
+	}
+}
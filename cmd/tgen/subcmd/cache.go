@@ -0,0 +1,199 @@
+package subcmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// paramsCache 解析結果(テンプレートのパラメータのjson)のディスク上のキャッシュ
+// キーはテスト対象のファイルのパスと内容・テスト対象のパッケージと、そこからimportしている同じモジュール内のパッケージの全てのファイルの内容・go.mod・go.sum・
+// 解析に影響するオプション・tgenの実行ファイルから作成する
+type paramsCache struct {
+	dir string
+	// tgenの実行ファイルの情報(tgen自体が更新された場合に、以前の解析結果を利用しないため)
+	executable string
+}
+
+// newParamsCache ユーザーのキャッシュディレクトリ配下のキャッシュを返す
+// キャッシュディレクトリが利用できない場合はnilを返す(キャッシュを利用しない)
+func newParamsCache() *paramsCache {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil
+	}
+	c := &paramsCache{dir: filepath.Join(cacheDir, "tgen", "params")}
+	if path, err := os.Executable(); err == nil {
+		if info, err := os.Stat(path); err == nil {
+			c.executable = fmt.Sprintf("%s:%d:%d", path, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return c
+}
+
+// key テスト対象のファイルのキャッシュのキーを作成する
+func (c *paramsCache) key(path string, s *settings) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%t\x00%t\x00", c.executable, s.TypeArgs, s.Lang, s.Assertion, s.Bench, s.Fuzz)
+	// 同じパッケージのファイルでも、テスト対象のファイルごとに解析結果は異なる
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(h, "%s\x00", absPath)
+	if err := hashFile(h, path); err != nil {
+		return "", err
+	}
+	h.Write([]byte{0})
+	modRoot, modPath := findModule(filepath.Dir(path))
+	files, err := sourceFiles(filepath.Dir(path), modRoot, modPath)
+	if err != nil {
+		return "", err
+	}
+	if modRoot != "" {
+		files = append(files, filepath.Join(modRoot, "go.mod"))
+		if goSum := filepath.Join(modRoot, "go.sum"); fileExists(goSum) {
+			files = append(files, goSum)
+		}
+	}
+	for _, file := range files {
+		fmt.Fprintf(h, "%s\x00", file)
+		if err := hashFile(h, file); err != nil {
+			return "", err
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sourceFiles テスト対象のパッケージと、そこから辿れる同じモジュール内のパッケージの、テスト以外のgoファイルを返す
+// パッケージを読み込まずにキーを作成するため、ビルドタグは考慮せずに全てのファイルのimportを辿る
+// モジュール外のパッケージの変更は、go.modとgo.sumの変更として検知する
+func sourceFiles(dir, modRoot, modPath string) ([]string, error) {
+	files := make([]string, 0)
+	visited := map[string]bool{}
+	var visit func(dir string, root bool) error
+	visit = func(dir string, root bool) error {
+		if visited[dir] {
+			return nil
+		}
+		visited[dir] = true
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			// importしているパッケージが見つからない場合は、解析時にエラーになる
+			if !root && errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+				continue
+			}
+			file := filepath.Join(dir, name)
+			files = append(files, file)
+			if modPath == "" {
+				continue
+			}
+			f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ImportsOnly)
+			if err != nil {
+				// 構文エラーがあるファイルも、内容はキーに含まれる
+				continue
+			}
+			for _, spec := range f.Imports {
+				importPath, err := strconv.Unquote(spec.Path.Value)
+				if err != nil || (importPath != modPath && !strings.HasPrefix(importPath, modPath+"/")) {
+					continue
+				}
+				importDir := filepath.Join(modRoot, filepath.FromSlash(strings.TrimPrefix(importPath, modPath)))
+				if err := visit(importDir, false); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := visit(absDir, true); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// get キーに対応する解析結果を返す
+func (c *paramsCache) get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(filepath.Join(c.dir, key+".json"))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// put 解析結果を保存する
+// 並行して同じキーを書き込んでも壊れないように、一時ファイルに書き込んでから置き換える
+func (c *paramsCache) put(key string, data []byte) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(c.dir, key+".json"))
+}
+
+func hashFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// findModule ディレクトリから上位に向かってgo.modを探し、そのディレクトリとモジュールのパスを返す
+// go.modがない場合は空文字を返す
+func findModule(startDir string) (root, path string) {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return "", ""
+	}
+	for {
+		if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, modfile.ModulePath(data)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,148 @@
+package subcmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeModule ディレクトリにモジュールのファイルを作成する(キーはモジュールのルートからの相対パス)
+func writeModule(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParamsCache_key(t *testing.T) {
+	module := map[string]string{
+		"go.mod":          "module example.com/m\n\ngo 1.20\n",
+		"svc/svc.go":      "package svc\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/m/dep\"\n)\n\nvar _ = fmt.Sprint(dep.V)\n",
+		"svc/svc_test.go": "package svc\n",
+		"svc/README.md":   "svc\n",
+		"dep/dep.go":      "package dep\n\nimport \"example.com/m/dep/inner\"\n\nvar V = inner.V\n",
+		"dep/inner/in.go": "package inner\n\nvar V = 1\n",
+		"other/other.go":  "package other\n",
+	}
+	tests := []struct {
+		name string
+		// キーを作成した後に、モジュールのファイルを変更・追加する
+		changes map[string]string
+		// キーを作成した後に、設定を変更する
+		changeSettings func(s *settings)
+		wantChanged    bool
+	}{
+		{
+			name:        "target package",
+			changes:     map[string]string{"svc/svc.go": "package svc\n\nvar V = 2\n"},
+			wantChanged: true,
+		},
+		{
+			name:        "new file in the target package",
+			changes:     map[string]string{"svc/new.go": "package svc\n"},
+			wantChanged: true,
+		},
+		{
+			name:        "imported package",
+			changes:     map[string]string{"dep/dep.go": "package dep\n\nimport \"example.com/m/dep/inner\"\n\nvar V = inner.V + 1\n"},
+			wantChanged: true,
+		},
+		{
+			name:        "transitively imported package",
+			changes:     map[string]string{"dep/inner/in.go": "package inner\n\nvar V = 2\n"},
+			wantChanged: true,
+		},
+		{
+			name:        "go.mod",
+			changes:     map[string]string{"go.mod": "module example.com/m\n\ngo 1.21\n"},
+			wantChanged: true,
+		},
+		{
+			name:        "go.sum",
+			changes:     map[string]string{"go.sum": "example.com/x v1.0.0 h1:abc=\n"},
+			wantChanged: true,
+		},
+		{
+			name:           "settings",
+			changeSettings: func(s *settings) { s.Lang = "ja" },
+			wantChanged:    true,
+		},
+		{
+			name:        "package not imported",
+			changes:     map[string]string{"other/other.go": "package other\n\nvar V = 1\n"},
+			wantChanged: false,
+		},
+		{
+			name:        "test file and non go file",
+			changes:     map[string]string{"svc/svc_test.go": "package svc\n\nvar T = 1\n", "svc/README.md": "changed\n"},
+			wantChanged: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeModule(t, dir, module)
+			c := &paramsCache{dir: t.TempDir(), executable: "tgen"}
+			s := &settings{Lang: "en", Assertion: "assert"}
+			target := filepath.Join(dir, "svc", "svc.go")
+			before, err := c.key(target, s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeModule(t, dir, tt.changes)
+			if tt.changeSettings != nil {
+				tt.changeSettings(s)
+			}
+			after, err := c.key(target, s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed := before != after; changed != tt.wantChanged {
+				t.Errorf("key changed = %t, want %t", changed, tt.wantChanged)
+			}
+		})
+	}
+}
+
+// TestParamsCache_keyPerFile 同じパッケージのファイルでも、テスト対象のファイルごとにキーが異なることを確認する
+func TestParamsCache_keyPerFile(t *testing.T) {
+	dir := t.TempDir()
+	writeModule(t, dir, map[string]string{
+		"go.mod":   "module example.com/m\n\ngo 1.20\n",
+		"svc/a.go": "package svc\n",
+		"svc/b.go": "package svc\n",
+	})
+	c := &paramsCache{dir: t.TempDir(), executable: "tgen"}
+	s := &settings{Lang: "en"}
+	a, err := c.key(filepath.Join(dir, "svc", "a.go"), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := c.key(filepath.Join(dir, "svc", "b.go"), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Errorf("key(a.go) = key(b.go) = %s, want different keys", a)
+	}
+}
+
+func TestParamsCache_putGet(t *testing.T) {
+	c := &paramsCache{dir: filepath.Join(t.TempDir(), "params")}
+	if _, ok := c.get("key"); ok {
+		t.Fatal("get() before put() = ok, want not found")
+	}
+	if err := c.put("key", []byte(`{"Lang":"en"}`)); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := c.get("key")
+	if !ok || string(got) != `{"Lang":"en"}` {
+		t.Errorf("get() = %s, %t, want %s", got, ok, `{"Lang":"en"}`)
+	}
+}
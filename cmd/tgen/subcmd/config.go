@@ -0,0 +1,401 @@
+package subcmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/kazdevl/tgen/internal"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ConfigFlag = "config"
+)
+
+// configFileNames 探索する設定ファイル名(先に見つかったものを利用する)
+var configFileNames = []string{".tgen.yaml", ".tgen.yml", ".tgen.toml"}
+
+// mockBackendImportPaths mockのライブラリごとのgomockのimportパス
+var mockBackendImportPaths = map[string]string{
+	"golang/mock": "github.com/golang/mock/gomock",
+	"uber/mock":   "go.uber.org/mock/gomock",
+}
+
+// Config リポジトリごとの設定ファイル(.tgen.yaml/.tgen.toml)の内容
+// 値が設定されていない項目は、コマンドのオプションの初期値が利用される
+type Config struct {
+	Only        string           `yaml:"only" toml:"only"`
+	Exported    *bool            `yaml:"exported" toml:"exported"`
+	Excl        string           `yaml:"excl" toml:"excl"`
+	TemplateDir string           `yaml:"template_dir" toml:"template_dir"`
+	PrintInputs *bool            `yaml:"print_inputs" toml:"print_inputs"`
+	Parallel    *bool            `yaml:"parallel" toml:"parallel"`
+	TypeArgs    string           `yaml:"type_args" toml:"type_args"`
+	Gotests     string           `yaml:"gotests" toml:"gotests"`
+	MockBackend string           `yaml:"mock_backend" toml:"mock_backend"`
+	Lang        string           `yaml:"lang" toml:"lang"`
+	Assertion   string           `yaml:"assertion" toml:"assertion"`
+	Bench       *bool            `yaml:"bench" toml:"bench"`
+	Fuzz        *bool            `yaml:"fuzz" toml:"fuzz"`
+	Strict      *bool            `yaml:"strict" toml:"strict"`
+	Packages    []*PackageConfig `yaml:"packages" toml:"packages"`
+
+	// 設定ファイルのパス(相対パスの基準として利用する)
+	path string
+}
+
+// PackageConfig パッケージごとに上書きする設定
+type PackageConfig struct {
+	// 対象のパッケージのディレクトリ(設定ファイルからの相対パス, 「/...」で配下の全パッケージを対象にする)
+	Path        string `yaml:"path" toml:"path"`
+	TemplateDir string `yaml:"template_dir" toml:"template_dir"`
+	MockBackend string `yaml:"mock_backend" toml:"mock_backend"`
+	Lang        string `yaml:"lang" toml:"lang"`
+	Assertion   string `yaml:"assertion" toml:"assertion"`
+	Excl        string `yaml:"excl" toml:"excl"`
+}
+
+// settings テスト対象のファイルごとに、オプション・設定ファイル・初期値を解決した設定
+type settings struct {
+	Only        string
+	Exported    bool
+	Excl        string
+	TemplateDir string
+	PrintInputs bool
+	Parallel    bool
+	TypeArgs    string
+	Gotests     string
+	MockBackend string
+	Lang        string
+	Assertion   string
+	Bench       bool
+	Fuzz        bool
+	Strict      bool
+}
+
+// findConfig 指定されたディレクトリから上位に向かって設定ファイルを探す
+// 見つからない場合はnilを返す
+func findConfig(startDir string) (*Config, error) {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		for _, name := range configFileNames {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return loadConfig(path)
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// loadConfig 設定ファイルを読み込む
+// 未知の項目が含まれている場合はエラーにする
+func loadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	switch filepath.Ext(path) {
+	case ".toml":
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) != 0 {
+			return nil, errors.New(localize("config.unknown_fields", path, undecoded))
+		}
+	default:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	cfg.path, err = filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// validate 設定ファイルの内容が正しいかを確認する
+func (c *Config) validate() error {
+	var errs []string
+	check := func(prefix, only, excl, templateDir, mockBackend, lang, assertion string) {
+		for name, expr := range map[string]string{"only": only, "excl": excl} {
+			if _, err := regexp.Compile(expr); expr != "" && err != nil {
+				errs = append(errs, localize("config.invalid_regexp", prefix, name, err))
+			}
+		}
+		if templateDir != "" {
+			if info, err := os.Stat(c.resolvePath(templateDir)); err != nil || !info.IsDir() {
+				errs = append(errs, localize("config.template_dir_missing", prefix, templateDir))
+			}
+		}
+		if _, ok := mockBackendImportPaths[mockBackend]; mockBackend != "" && !ok {
+			errs = append(errs, localize("config.invalid_mock_backend", prefix, mockBackend))
+		}
+		if lang != "" && lang != "ja" && lang != "en" {
+			errs = append(errs, localize("config.invalid_lang", prefix, lang))
+		}
+		if assertion != "" && !internal.IsValidAssertion(assertion) {
+			errs = append(errs, localize("config.invalid_assertion", prefix, assertion))
+		}
+	}
+	check("", c.Only, c.Excl, c.TemplateDir, c.MockBackend, c.Lang, c.Assertion)
+	if _, err := parseTypeArgs(c.TypeArgs); err != nil {
+		errs = append(errs, err.Error())
+	}
+	for i, p := range c.Packages {
+		if p.Path == "" {
+			errs = append(errs, localize("config.path_missing", i))
+		}
+		check(localize("config.package_prefix", i, p.Path), "", p.Excl, p.TemplateDir, p.MockBackend, p.Lang, p.Assertion)
+	}
+	if len(errs) != 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// resolvePath 設定ファイルに記載された相対パスを、設定ファイルのディレクトリを基準に解決する
+func (c *Config) resolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(c.path), path)
+}
+
+// packageConfig テスト対象のファイルに該当するパッケージごとの設定を返す
+// 複数該当する場合は、より深いディレクトリを対象にした設定を優先する
+func (c *Config) packageConfig(targetPath string) *PackageConfig {
+	absTarget, err := filepath.Abs(targetPath)
+	if err != nil {
+		return nil
+	}
+	rel, err := filepath.Rel(filepath.Dir(c.path), filepath.Dir(absTarget))
+	if err != nil {
+		return nil
+	}
+	rel = filepath.ToSlash(rel)
+	var matched *PackageConfig
+	for _, p := range c.Packages {
+		path := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(p.Path)), "./")
+		isMatched := rel == path
+		if strings.HasSuffix(path, "/...") {
+			base := strings.TrimSuffix(path, "/...")
+			isMatched = rel == base || strings.HasPrefix(rel, base+"/") || base == "."
+		}
+		if isMatched && (matched == nil || len(p.Path) > len(matched.Path)) {
+			matched = p
+		}
+	}
+	return matched
+}
+
+// resolveSettings オプション・設定ファイル・初期値の順に優先して、テスト対象のファイルの設定を解決する
+func resolveSettings(cCtx *cli.Context, targetPath string) (*settings, error) {
+	cfg, err := loadConfigForTarget(cCtx, targetPath)
+	if err != nil {
+		return nil, err
+	}
+	s := &settings{
+		Only:        cCtx.String(OnlyFlag),
+		Exported:    cCtx.Bool(ExportedFlag),
+		Excl:        cCtx.String(ExclFlag),
+		TemplateDir: cCtx.String(TemplateDirFlag),
+		PrintInputs: cCtx.Bool(PrintTestInputsFlag),
+		Parallel:    cCtx.Bool(ParallelFlag),
+		TypeArgs:    cCtx.String(TypeArgsFlag),
+		Assertion:   cCtx.String(AssertionFlag),
+		Bench:       cCtx.Bool(BenchFlag),
+		Fuzz:        cCtx.Bool(FuzzFlag),
+		Strict:      cCtx.Bool(StrictFlag),
+		Gotests:     gotestsName,
+		Lang:        langFromEnv(),
+	}
+	if cCtx.IsSet(LangFlag) {
+		s.Lang = internal.NormalizeLang(cCtx.String(LangFlag))
+	}
+	if anotherNamedGotests := os.Getenv(envKey); anotherNamedGotests != "" {
+		s.Gotests = anotherNamedGotests
+	}
+	if cfg == nil {
+		return s, s.validateAssertion()
+	}
+
+	setString := func(flagName string, dest *string, values ...string) {
+		if flagName != "" && cCtx.IsSet(flagName) {
+			return
+		}
+		for _, v := range values {
+			if v != "" {
+				*dest = v
+			}
+		}
+	}
+	setBool := func(flagName string, dest *bool, value *bool) {
+		if cCtx.IsSet(flagName) || value == nil {
+			return
+		}
+		*dest = *value
+	}
+	pkgCfg := cfg.packageConfig(targetPath)
+	if pkgCfg == nil {
+		pkgCfg = new(PackageConfig)
+	}
+	templateDir := cfg.TemplateDir
+	if templateDir != "" {
+		templateDir = cfg.resolvePath(templateDir)
+	}
+	pkgTemplateDir := pkgCfg.TemplateDir
+	if pkgTemplateDir != "" {
+		pkgTemplateDir = cfg.resolvePath(pkgTemplateDir)
+	}
+	setString(OnlyFlag, &s.Only, cfg.Only)
+	setString(ExclFlag, &s.Excl, joinPatterns(cfg.Excl, pkgCfg.Excl))
+	setString(TemplateDirFlag, &s.TemplateDir, templateDir, pkgTemplateDir)
+	setString(TypeArgsFlag, &s.TypeArgs, cfg.TypeArgs)
+	setString("", &s.MockBackend, cfg.MockBackend, pkgCfg.MockBackend)
+	setString(LangFlag, &s.Lang, cfg.Lang, pkgCfg.Lang)
+	setString(AssertionFlag, &s.Assertion, cfg.Assertion, pkgCfg.Assertion)
+	setBool(ExportedFlag, &s.Exported, cfg.Exported)
+	setBool(PrintTestInputsFlag, &s.PrintInputs, cfg.PrintInputs)
+	setBool(ParallelFlag, &s.Parallel, cfg.Parallel)
+	setBool(BenchFlag, &s.Bench, cfg.Bench)
+	setBool(FuzzFlag, &s.Fuzz, cfg.Fuzz)
+	setBool(StrictFlag, &s.Strict, cfg.Strict)
+	if cfg.Gotests != "" && os.Getenv(envKey) == "" {
+		s.Gotests = cfg.Gotests
+	}
+	return s, s.validateAssertion()
+}
+
+// joinPatterns いずれかの正規表現に合致する場合に合致する正規表現を作成する
+// packagesのexclは、設定ファイル全体のexclを置き換えずに、除外する対象を追加する
+func joinPatterns(patterns ...string) string {
+	nonEmpty := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	if len(nonEmpty) <= 1 {
+		return strings.Join(nonEmpty, "")
+	}
+	return "(?:" + strings.Join(nonEmpty, ")|(?:") + ")"
+}
+
+// validateAssertion オプションもしくは設定ファイルで指定された、テストの結果の比較に利用するライブラリを確認する
+// 指定がない場合は、testify/assertを利用する
+func (s *settings) validateAssertion() error {
+	if s.Assertion != "" && !internal.IsValidAssertion(s.Assertion) {
+		return errors.New(localize("config.invalid_assertion", "", s.Assertion))
+	}
+	return nil
+}
+
+// loadConfigForTarget オプションで指定された設定ファイル、もしくはテスト対象のファイルから探した設定ファイルを読み込む
+func loadConfigForTarget(cCtx *cli.Context, targetPath string) (*Config, error) {
+	if path := cCtx.String(ConfigFlag); path != "" {
+		return loadConfig(path)
+	}
+	return findConfig(filepath.Dir(targetPath))
+}
+
+func generateConfigCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "manage .tgen.yaml/.tgen.toml",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "validate",
+				Usage:     "validate the configuration file",
+				ArgsUsage: localize("usage.validate_args"),
+				Action:    validateConfigAction,
+			},
+		},
+	}
+}
+
+func validateConfigAction(cCtx *cli.Context) error {
+	target := cCtx.Args().First()
+	if target == "" {
+		target = "."
+	}
+	var cfg *Config
+	var err error
+	if info, statErr := os.Stat(target); statErr == nil && info.IsDir() {
+		cfg, err = findConfig(target)
+	} else {
+		cfg, err = loadConfig(target)
+	}
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		return errors.New(localize("config.not_found"))
+	}
+	if err := cfg.validate(); err != nil {
+		return fmt.Errorf("%s:\n%w", cfg.path, err)
+	}
+	fmt.Printf("%s: OK\n", cfg.path)
+	return nil
+}
+
+// replaceMockImport 生成されたテストファイルのgomockのimportパスを、設定されたmockのライブラリのものに置き換える
+// goimportsによって補完されるimportパスは、利用するmockのライブラリと一致するとは限らないため
+// mockBackendが空の場合は何もしない
+func replaceMockImport(testFilePath, mockBackend string) error {
+	if mockBackend == "" {
+		return nil
+	}
+	data, err := os.ReadFile(testFilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		// テストが生成されなかった場合
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	replaced, err := replaceMockImportContent(data, mockBackend)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(replaced, data) {
+		return nil
+	}
+	return os.WriteFile(testFilePath, replaced, 0644)
+}
+
+// replaceMockImportContent テストコードのgomockのimportパスを、設定されたmockのライブラリのものに置き換えた内容を返す
+func replaceMockImportContent(data []byte, mockBackend string) ([]byte, error) {
+	importPath, ok := mockBackendImportPaths[mockBackend]
+	if !ok {
+		return nil, errors.New(localize("config.invalid_mock_backend", "", mockBackend))
+	}
+	replaced := data
+	for _, other := range mockBackendImportPaths {
+		if other == importPath {
+			continue
+		}
+		replaced = bytes.ReplaceAll(replaced, []byte(`"`+other+`"`), []byte(`"`+importPath+`"`))
+	}
+	return replaced, nil
+}
@@ -0,0 +1,122 @@
+package subcmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+const testConfig = `lang: en
+mock_backend: golang/mock
+parallel: true
+excl: ^Skip
+template_dir: tmpl
+packages:
+  - path: internal/...
+    lang: ja
+    mock_backend: uber/mock
+  - path: internal/legacy
+    lang: en
+    excl: ^Legacy
+    template_dir: internal/legacy/tmpl
+`
+
+// resolvedSettings 設定の解決結果のうち、確認する項目
+type resolvedSettings struct {
+	Lang, MockBackend, Excl, TemplateDir string
+	Parallel                             bool
+}
+
+// runResolveSettings createコマンドのオプションを解析し、テスト対象のファイルの設定を解決する
+func runResolveSettings(t *testing.T, args []string, targetPath string) resolvedSettings {
+	t.Helper()
+	var got *settings
+	cmd := generateCreateCommand()
+	cmd.Action = func(cCtx *cli.Context) error {
+		var err error
+		got, err = resolveSettings(cCtx, targetPath)
+		return err
+	}
+	app := &cli.App{Commands: []*cli.Command{cmd}}
+	if err := app.Run(append([]string{"tgen", "create"}, args...)); err != nil {
+		t.Fatalf("resolveSettings(%q) error = %v", targetPath, err)
+	}
+	return resolvedSettings{Lang: got.Lang, MockBackend: got.MockBackend, Excl: got.Excl, TemplateDir: got.TemplateDir, Parallel: got.Parallel}
+}
+
+func TestResolveSettings(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".tgen.yaml"), []byte(testConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		args       []string
+		targetPath string
+		want       resolvedSettings
+	}{
+		{
+			name:       "top-level settings of the config file",
+			targetPath: filepath.Join(dir, "service.go"),
+			want:       resolvedSettings{Lang: "en", MockBackend: "golang/mock", Excl: "^Skip", TemplateDir: filepath.Join(dir, "tmpl"), Parallel: true},
+		},
+		{
+			name:       "package settings override the top-level settings",
+			targetPath: filepath.Join(dir, "internal", "user", "service.go"),
+			want:       resolvedSettings{Lang: "ja", MockBackend: "uber/mock", Excl: "^Skip", TemplateDir: filepath.Join(dir, "tmpl"), Parallel: true},
+		},
+		{
+			name:       "the deepest package settings win and its excl is added to the top-level excl",
+			targetPath: filepath.Join(dir, "internal", "legacy", "service.go"),
+			want:       resolvedSettings{Lang: "en", MockBackend: "golang/mock", Excl: "(?:^Skip)|(?:^Legacy)", TemplateDir: filepath.Join(dir, "internal", "legacy", "tmpl"), Parallel: true},
+		},
+		{
+			name:       "options override the config file",
+			args:       []string{"--lang", "en", "--parallel=false", "--excl", "^Test", "--template_dir", "custom"},
+			targetPath: filepath.Join(dir, "internal", "user", "service.go"),
+			want:       resolvedSettings{Lang: "en", MockBackend: "uber/mock", Excl: "^Test", TemplateDir: "custom"},
+		},
+		{
+			name:       "without config file",
+			args:       []string{"--lang", "ja"},
+			targetPath: filepath.Join(t.TempDir(), "service.go"),
+			want:       resolvedSettings{Lang: "ja", TemplateDir: "template"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runResolveSettings(t, tt.args, tt.targetPath); got != tt.want {
+				t.Errorf("resolveSettings(%q) = %+v, want %+v", tt.targetPath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		fileName string
+		content  string
+		wantErr  bool
+	}{
+		{fileName: ".tgen.yaml", content: "lang: en\n"},
+		{fileName: ".tgen.toml", content: "lang = \"en\"\n"},
+		{fileName: ".tgen.yaml", content: "language: en\n", wantErr: true},
+		{fileName: ".tgen.toml", content: "language = \"en\"\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), tt.fileName)
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := loadConfig(path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("loadConfig(%q) error = %v, wantErr %v", tt.content, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got.Lang != "en" {
+			t.Errorf("loadConfig(%q).Lang = %q, want en", tt.content, got.Lang)
+		}
+	}
+}
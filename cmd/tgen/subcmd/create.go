@@ -2,9 +2,18 @@ package subcmd
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/kazdevl/tgen"
 	"github.com/urfave/cli/v2"
@@ -21,60 +30,273 @@ func generateCreateCommand() *cli.Command {
 		Aliases: []string{"c"},
 		Usage:   "create test code",
 		Action:  createAction,
-		Flags:   getCommonFlags(),
+		Flags: append(getCommonFlags(),
+			&cli.IntFlag{
+				Name: JobsFlag, Usage: localize("usage.jobs"), Value: runtime.NumCPU(),
+			},
+			&cli.BoolFlag{
+				Name: NoCacheFlag, Usage: localize("usage.no_cache"), Value: false,
+			},
+			&cli.BoolFlag{
+				Name: UpdateFlag, Usage: localize("usage.update"), Value: false,
+			},
+		),
 	}
 }
 
-func createAction(cCtx *cli.Context) error {
-	templateDir := cCtx.String(TemplateDirFlag)
-	// 引数にはファイル名が入る想定
-	for i, targetFilePath := range cCtx.Args().Slice() {
-		// テンプレート用のパラメータを格納するjsonファイルの作成
-		paramFilePath := fmt.Sprintf("%s/param_%d.json", templateDir, i)
-		f, err := os.Create(paramFilePath)
-		if err != nil {
-			return err
-		}
-		// ツールの使用目的に大量のファイルに対して一度に実行するケースはほぼないため
-		// forループの中でdeferの呼び出しを許容している
+// createTarget テスト対象のファイルごとの処理の状態
+type createTarget struct {
+	// 引数での順番(テンプレート用のパラメータのファイル名に利用する)
+	index int
+	path  string
+	s     *settings
+	// 型パラメータ名と、テストで利用する具体的な型の組み合わせ
+	typeArgs map[string]string
+	// 既存のテスト関数に、ないテストケースを追加するか
+	update bool
+	// 解析結果のキャッシュのキー(キャッシュを利用しない場合は空文字)
+	cacheKey string
+	// jsonにしたテンプレート用のパラメータ(解析に失敗した場合はnil)
+	params []byte
+	// 解析結果(キャッシュを利用した場合は、Paramsのみを持つ)
+	result *tgen.Result
+	// 解析に失敗した原因
+	analyzeErr error
+	// テストコードの生成に失敗した原因
+	generateErr error
+	// 既存のテストファイルに反映した結果(updateの場合のみ)
+	updateResult *tgen.UpdateResult
+	// 表示する内容(並行して処理するため、全ての処理の後に引数の順番で表示する)
+	out bytes.Buffer
+}
+
+func createAction(cCtx *cli.Context) (err error) {
+	r := new(report)
+	if reportPath := cCtx.String(ReportFlag); reportPath != "" {
+		// 途中で失敗した場合も、それまでに解析した内容を出力する
 		defer func() {
-			f.Close()
-			os.Remove(paramFilePath)
+			if writeErr := r.write(reportPath); err == nil {
+				err = writeErr
+			}
 		}()
+	}
+	var cache *paramsCache
+	if !cCtx.Bool(NoCacheFlag) {
+		cache = newParamsCache()
+	}
 
-		// オプションの用意
-		options := createCommonFlagOptionsForGotests(cCtx)
+	// 引数にはファイル名が入る想定
+	targets, err := newCreateTargets(cCtx, cCtx.Args().Slice(), cache, cCtx.Bool(UpdateFlag))
+	if err != nil {
+		return err
+	}
+	runTargets(cCtx, targets, func(group []*createTarget) {
+		analyzeGroup(cCtx.Context, group, cache)
+		for _, t := range group {
+			t.generateErr = t.generate()
+		}
+	})
+
+	for _, t := range targets {
+		os.Stdout.Write(t.out.Bytes())
+		if t.updateResult != nil {
+			printUpdateResult(os.Stdout, t.path, t.updateResult)
+		}
+		if cCtx.Bool(SuggestClockFlag) {
+			printClockSuggestions(os.Stdout, t.result)
+		}
+		r.add(t.path, t.result, t.analyzeErr)
+		if t.analyzeErr != nil && t.s.Strict {
+			return t.analyzeErr
+		}
+		if t.generateErr != nil {
+			return t.generateErr
+		}
+	}
+	return nil
+}
 
-		// jsonファイルにテンプレート用のパラメータを入れる
-		var parameters []byte
-		parameters, err = tgen.CreateParameterWithFilePath(targetFilePath)
+// newCreateTargets テスト対象のファイルごとに、オプション・設定ファイルから設定を解決し、解析結果のキャッシュを読み込む
+func newCreateTargets(cCtx *cli.Context, paths []string, cache *paramsCache, update bool) ([]*createTarget, error) {
+	targets := make([]*createTarget, 0, len(paths))
+	for i, targetFilePath := range paths {
+		s, err := resolveSettings(cCtx, targetFilePath)
 		if err != nil {
-			fmt.Printf("tgenの実行時にerrorが発生しました。\n既存のgotestsをそのまま利用します。err=%+v\n", err)
-		} else {
-			options = append(options,
-				"-template_params_file="+paramFilePath,
-				"-template_dir="+templateDir,
-			)
-			if _, err = f.Write(parameters); err != nil {
-				return err
+			return nil, err
+		}
+		typeArgs, err := parseTypeArgs(s.TypeArgs)
+		if err != nil {
+			return nil, err
+		}
+		t := &createTarget{index: i, path: targetFilePath, s: s, typeArgs: typeArgs, update: update}
+		if cache != nil {
+			if t.cacheKey, err = cache.key(targetFilePath, s); err != nil {
+				return nil, err
 			}
+			t.params, _ = cache.get(t.cacheKey)
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// runTargets 同じディレクトリで解析のオプションが同じファイルをまとめ、--jobsで指定された数まで並行してprocessを呼び出す
+// まとめたファイルは、パッケージを一度だけ読み込んで解析する
+func runTargets(cCtx *cli.Context, targets []*createTarget, process func(group []*createTarget)) {
+	groupKeys := make([]string, 0)
+	groups := map[string][]*createTarget{}
+	for _, t := range targets {
+		groupKey := strings.Join([]string{filepath.Dir(t.path), t.s.TypeArgs, t.s.Lang, t.s.Assertion, strconv.FormatBool(t.s.Bench), strconv.FormatBool(t.s.Fuzz)}, "\x00")
+		if _, ok := groups[groupKey]; !ok {
+			groupKeys = append(groupKeys, groupKey)
+		}
+		groups[groupKey] = append(groups[groupKey], t)
+	}
+
+	jobs := cCtx.Int(JobsFlag)
+	if jobs < 1 {
+		jobs = 1
+	}
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for _, groupKey := range groupKeys {
+		group := groups[groupKey]
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			process(group)
+		}()
+	}
+	wg.Wait()
+}
+
+// analyzeGroup 同じディレクトリのテスト対象のファイルを解析する
+// キャッシュがないファイルのみを、パッケージを一度だけ読み込んで解析する
+func analyzeGroup(ctx context.Context, group []*createTarget, cache *paramsCache) {
+	uncached := make([]*createTarget, 0, len(group))
+	for _, t := range group {
+		if t.params == nil {
+			uncached = append(uncached, t)
+			continue
+		}
+		params := new(tgen.TemplateParams)
+		if err := json.Unmarshal(t.params, params); err != nil {
+			// 壊れたキャッシュは利用せずに解析し直す
+			t.params = nil
+			uncached = append(uncached, t)
+			continue
 		}
+		t.result = &tgen.Result{Path: t.path, Params: params}
+	}
+	if len(uncached) == 0 {
+		return
+	}
+	paths := make([]string, 0, len(uncached))
+	for _, t := range uncached {
+		paths = append(paths, t.path)
+	}
+	// グループ内のファイルは、解析のオプションが同じ
+	s := uncached[0].s
+	results, errs := tgen.AnalyzeFiles(ctx, paths, tgen.WithTypeArgs(uncached[0].typeArgs), tgen.WithLang(s.Lang), tgen.WithAssertion(s.Assertion), tgen.WithBench(s.Bench), tgen.WithFuzz(s.Fuzz))
+	for i, t := range uncached {
+		t.result, t.analyzeErr = results[i], errs[i]
+		if t.analyzeErr != nil {
+			continue
+		}
+		if t.params, t.analyzeErr = json.Marshal(t.result.Params); t.analyzeErr != nil {
+			continue
+		}
+		if cache != nil && t.cacheKey != "" {
+			// キャッシュは次回の実行を速くするためのものなので、保存に失敗しても処理を続ける
+			_ = cache.put(t.cacheKey, t.params)
+		}
+	}
+}
 
-		// goのテストコードを自動生成するコマンドの呼び出し
-		if err = callGotests(options, targetFilePath); err != nil {
+// generate テストコードを生成する
+// 解析に失敗した場合は、--strictの指定がない場合のみgotestsのみで生成する
+func (t *createTarget) generate() error {
+	if t.analyzeErr == nil && t.update {
+		return t.updateTestFile()
+	}
+	// オプションの用意
+	options := createCommonFlagOptionsForGotests(t.s)
+	if t.analyzeErr != nil {
+		if t.s.Strict {
+			return nil
+		}
+		fmt.Fprint(&t.out, localize("create.fallback", t.analyzeErr))
+	} else {
+		// テンプレート用のパラメータを格納するjsonファイルの作成
+		paramFilePath := fmt.Sprintf("%s/param_%d.json", t.s.TemplateDir, t.index)
+		if err := os.WriteFile(paramFilePath, t.params, 0644); err != nil {
 			return err
 		}
+		defer os.Remove(paramFilePath)
+		options = append(options,
+			"-template_params_file="+paramFilePath,
+			"-template_dir="+t.s.TemplateDir,
+		)
+		printDiagnostics(&t.out, t.result.Params.Diagnostics)
 	}
-	return nil
+
+	// goのテストコードを自動生成するコマンドの呼び出し
+	if err := callGotests(&t.out, t.s.Gotests, options, t.path); err != nil {
+		return err
+	}
+	return replaceMockImport(t.testFilePath(), t.s.MockBackend)
 }
 
-func callGotests(options []string, targetFilePath string) error {
-	// 環境変数にANOTHER_NAMED_GOTESTSが設定されている場合
-	// 別名にしたgotestsを参照するようにする
-	anotherNamedGotests := os.Getenv(envKey)
-	if anotherNamedGotests != "" {
-		gotestsName = anotherNamedGotests
+// updateTestFile テストコードを生成し、既存のテストファイルに反映する
+// gotestsのコマンドは既存のテスト関数を生成しないため、ライブラリとして呼び出して生成する
+func (t *createTarget) updateTestFile() error {
+	opts, err := generateOptions(t.s)
+	if err != nil {
+		return err
+	}
+	templates, err := resolveTemplates(t.s)
+	if err != nil {
+		return err
+	}
+	existing, err := os.ReadFile(t.testFilePath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	printDiagnostics(&t.out, t.result.Params.Diagnostics)
+	t.updateResult, err = tgen.Update(t.result, templates, existing, opts...)
+	if err != nil {
+		return err
+	}
+	if t.updateResult.Content == nil || bytes.Equal(t.updateResult.Content, existing) {
+		return nil
+	}
+	if err := os.WriteFile(t.testFilePath(), t.updateResult.Content, 0644); err != nil {
+		return err
 	}
+	return replaceMockImport(t.testFilePath(), t.s.MockBackend)
+}
+
+func (t *createTarget) testFilePath() string {
+	return strings.TrimSuffix(t.path, ".go") + "_test.go"
+}
+
+// printDiagnostics 解析時に検出した内容のうち、利用者が対応する必要があるものを表示する
+// 仕様としてテストケースの生成の対象外とした構文は、--reportで出力する
+func printDiagnostics(w io.Writer, diagnostics []*tgen.Diagnostic) {
+	for _, d := range diagnostics {
+		if d.Severity != tgen.SeverityWarning {
+			continue
+		}
+		fmt.Fprintln(w, d)
+	}
+}
+
+// callGotests gotestsを呼び出す
+// gotestsName: 環境変数ANOTHER_NAMED_GOTESTSもしくは設定ファイルで別名が指定されている場合はその名前
+// w: 実行結果の表示先
+func callGotests(w io.Writer, gotestsName string, options []string, targetFilePath string) error {
 	// goのテストコードを自動生成するコマンドの呼び出し
 	cmdArgs := append(options, targetFilePath)
 	cmd := exec.Command(
@@ -88,7 +310,7 @@ func callGotests(options []string, targetFilePath string) error {
 	if err := cmd.Run(); err != nil {
 		return err
 	}
-	fmt.Printf("Stdout:\n%s\n", stdout.String())
+	fmt.Fprintf(w, "Stdout:\n%s\n", stdout.String())
 
 	return nil
 }
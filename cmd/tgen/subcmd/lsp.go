@@ -0,0 +1,81 @@
+package subcmd
+
+import (
+	"os"
+
+	"github.com/kazdevl/tgen"
+	"github.com/kazdevl/tgen/lsp"
+	"github.com/urfave/cli/v2"
+)
+
+func generateLSPCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "lsp",
+		Usage:  "run a language server over stdio that offers a code action to generate tests",
+		Action: lspAction,
+		Flags:  getLSPFlags(),
+	}
+}
+
+// getLSPFlags 言語サーバーで利用するオプション
+// テスト対象はコードアクションを実行したメソッドになるため、対象を絞り込むオプションは除く
+func getLSPFlags() []cli.Flag {
+	excluded := map[string]bool{
+		OnlyFlag:         true,
+		ExportedFlag:     true,
+		ExclFlag:         true,
+		StrictFlag:       true,
+		ReportFlag:       true,
+		SuggestClockFlag: true,
+	}
+	flags := make([]cli.Flag, 0)
+	for _, flag := range getCommonFlags() {
+		if !excluded[flag.Names()[0]] {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
+
+// lspAction 標準入出力で言語サーバーを動かす
+// 標準出力はプロトコルのメッセージに利用するため、他の出力は行わない
+func lspAction(cCtx *cli.Context) error {
+	server := lsp.NewServer(func(path string) (*lsp.Config, error) {
+		return resolveLSPConfig(cCtx, path)
+	})
+	return server.Serve(cCtx.Context, os.Stdin, os.Stdout)
+}
+
+// resolveLSPConfig オプション・設定ファイルから、テスト対象のファイルのテストコードの生成方法を決める
+func resolveLSPConfig(cCtx *cli.Context, path string) (*lsp.Config, error) {
+	s, err := resolveSettings(cCtx, path)
+	if err != nil {
+		return nil, err
+	}
+	typeArgs, err := parseTypeArgs(s.TypeArgs)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := resolveTemplates(s)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &lsp.Config{
+		Templates: templates,
+		Lang:      s.Lang,
+		Options: []tgen.Option{
+			tgen.WithTypeArgs(typeArgs),
+			tgen.WithAssertion(s.Assertion),
+			tgen.WithBench(s.Bench),
+			tgen.WithFuzz(s.Fuzz),
+			tgen.WithPrintInputs(s.PrintInputs),
+			tgen.WithParallel(s.Parallel),
+		},
+	}
+	if s.MockBackend != "" {
+		cfg.Postprocess = func(output []byte) ([]byte, error) {
+			return replaceMockImportContent(output, s.MockBackend)
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,146 @@
+package subcmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kazdevl/tgen/internal"
+)
+
+// cliLang オプションの説明やエラーなど、コマンドの表示に利用する言語
+// テスト対象のファイルを解析する前に決める必要があるため、コマンドの引数・カレントディレクトリの設定ファイル・環境変数から決める
+var cliLang = internal.LangJa
+
+// cliMessages 言語ごとの、コマンドの表示に利用するメッセージのキーと書式の組み合わせ
+var cliMessages = map[string]map[string]string{
+	internal.LangJa: {
+		"usage.only":                  "指定した正規表現に合致する関数もしくはメソッドに対してテストを生成する",
+		"usage.exported":              "公開されている関数もしくはメソッドに対してテストを生成する。onlyよりも優先される",
+		"usage.excl":                  "指定した正規表現に合致しない関数もしくはメソッドに対してテストを生成する。onlyとexportedよりも優先される",
+		"usage.template_dir":          "テストの生成に利用するテンプレートのディレクトリへのパス",
+		"usage.i":                     "エラーメッセージにテストの入力を出力するか",
+		"usage.parallel":              "サブテストを並行実行するテストコードを出力する",
+		"usage.type_args":             "型パラメータに利用する具体的な型を「型パラメータ名=型」のカンマ区切りで指定する(例: T=int,K=string)。指定がない型パラメータは制約から選択される",
+		"usage.config":                "設定ファイルへのパス。指定がない場合はテスト対象のファイルのディレクトリから上位に向かって.tgen.yaml/.tgen.tomlを探す",
+		"usage.lang":                  "テストケース名やメッセージの言語(ja, en)。指定がない場合は設定ファイル、環境変数LANGの順に決める",
+		"usage.assertion":             "テストの結果の比較に利用するライブラリ(assert: testify/assert, require: testify/require, cmp: go-cmp, std: 標準ライブラリのみ)。指定がない場合はassert",
+		"usage.bench":                 "テスト関数に加えて、正常系のmockを利用したベンチマーク関数(BenchmarkXxx)を生成する",
+		"usage.fuzz":                  "テスト関数に加えて、文字列・[]byte・数値・真偽値の引数を生成するfuzzテスト関数(FuzzXxx)を生成する",
+		"usage.strict":                "解析に失敗した場合に、gotestsのみでの生成に切り替えずにエラーにする",
+		"usage.report":                "解析結果(エラーと、テストケースの生成の対象外とした構文を含む検出内容)をjsonで出力するファイルへのパス。「-」の場合は標準出力に出力する",
+		"usage.suggest_clock":         "テスト対象の関数が呼び出している、実行ごとに結果が変わる関数(time.Now, rand.*, os.Getenv, uuid.Newなど)ごとに、差し替えられるように注入するフィールドの候補を表示する",
+		"usage.jobs":                  "解析とテストコードの生成を並行して行う数",
+		"usage.no_cache":              "解析結果のキャッシュを利用しない。キャッシュはテスト対象のファイルごとに保存され、テスト対象のパッケージとそこからimportしている同じモジュール内のパッケージのファイル・go.mod・go.sumが同じ場合に利用される",
+		"usage.update":                "既存のテストファイルがある場合は、既存のテスト関数を置き換えずに、テストケースがない分岐のテストケースをTODOコメント付きで追加する",
+		"usage.watch_args":            "[パッケージのパターン(./...など) もしくは ファイル]",
+		"usage.debounce":              "ファイルの変更を検知してから、テストファイルに反映するまでの待ち時間。この間に他の変更がある場合は、まとめて反映する",
+		"usage.poll":                  "指定した間隔でファイルの変更を確認する。指定しない場合はfsnotifyで変更を検知する",
+		"watch.start":                 "%d個のファイルの監視を開始しました。終了する場合はCtrl+Cを押してください",
+		"watch.fallback_polling":      "fsnotifyを利用できないため、ポーリングで監視します。err=%v",
+		"watch.added_func":            "%s: %sを追加しました",
+		"watch.added_cases":           "%s: %sにテストケース(%s)を追加しました",
+		"watch.removed_cases":         "%s: %sのテストケース(%s)に対応する分岐がなくなりました。テストケースは削除していません",
+		"watch.unchanged":             "%s: 追加したテストケースはありません",
+		"watch.error":                 "%sテストファイルに反映できませんでした。err=%v",
+		"usage.validate_args":         "[設定ファイル もしくは 探索を開始するディレクトリ]",
+		"create.fallback":             "tgenの実行時にerrorが発生しました。\n既存のgotestsをそのまま利用します。err=%+v\n",
+		"suggest_clock.existing":      "%[1]s: %[2]sの%[3]sは、%[4]sの既存のフィールド%[5]sで差し替えられます",
+		"suggest_clock.field":         "%[1]s: %[2]sの%[3]sを差し替えられるように、%[4]sにフィールド`%[5]s %[6]s`を追加して注入することを検討してください",
+		"suggest_clock.param":         "%[1]s: %[2]sの%[3]sを差し替えられるように、引数`%[5]s %[6]s`で受け取ることを検討してください",
+		"type_args.invalid":           "型パラメータの指定(%s)は「型パラメータ名=型」の形式である必要があります",
+		"config.unknown_fields":       "%s: 未知の項目があります: %v",
+		"config.invalid_regexp":       "%s%sの正規表現が不正です: %v",
+		"config.template_dir_missing": "%stemplate_dir(%s)がディレクトリとして存在しません",
+		"config.invalid_mock_backend": "%smock_backend(%s)はgolang/mockかuber/mockである必要があります",
+		"config.invalid_lang":         "%slang(%s)はjaかenである必要があります",
+		"config.invalid_assertion":    "%sassertion(%s)はassert・require・cmp・stdのいずれかである必要があります",
+		"config.path_missing":         "packages[%d]のpathが設定されていません",
+		"config.package_prefix":       "packages[%d](%s)の",
+		"config.not_found":            "設定ファイルが見つかりません",
+	},
+	internal.LangEn: {
+		"usage.only":                  "generate tests only for functions and methods that match the regular expression",
+		"usage.exported":              "generate tests for exported functions and methods. Takes precedence over only",
+		"usage.excl":                  "generate tests for functions and methods that do not match the regular expression. Takes precedence over only and exported",
+		"usage.template_dir":          "path to the directory of templates used to generate tests",
+		"usage.i":                     "print test inputs in error messages",
+		"usage.parallel":              "generate subtests that run in parallel",
+		"usage.type_args":             "concrete types for type parameters as comma separated \"name=type\" (e.g. T=int,K=string). Type parameters without one are chosen from their constraints",
+		"usage.config":                "path to the configuration file. If not set, .tgen.yaml/.tgen.toml is searched upward from the directory of the target file",
+		"usage.lang":                  "language of test case names and messages (ja, en). If not set, it is taken from the configuration file, then the LANG environment variable",
+		"usage.assertion":             "library used to compare test results (assert: testify/assert, require: testify/require, cmp: go-cmp, std: standard library only). Defaults to assert",
+		"usage.bench":                 "also generate benchmark functions (BenchmarkXxx) that reuse the mocks of the success case",
+		"usage.fuzz":                  "also generate fuzz tests (FuzzXxx) for functions whose arguments are strings, []byte, numbers or bools",
+		"usage.strict":                "fail instead of falling back to plain gotests when the analysis fails",
+		"usage.report":                "path to write the analysis report as JSON (errors and diagnostics, including constructs skipped for test cases). \"-\" writes to stdout",
+		"usage.suggest_clock":         "show which field to inject for each function whose result changes on each run (time.Now, rand.*, os.Getenv, uuid.New, etc.) called by the target functions",
+		"usage.jobs":                  "number of files to analyze and generate tests for in parallel",
+		"usage.no_cache":              "do not use cached analysis results. The cache is kept per target file and is used when the files of the target package and of the packages in the same module it imports, go.mod and go.sum are unchanged",
+		"usage.update":                "when the test file exists, keep existing test functions and add test cases with a TODO comment for branches that have none",
+		"usage.watch_args":            "[package patterns (e.g. ./...) or files]",
+		"usage.debounce":              "time to wait after a change before updating test files. Changes within this time are applied together",
+		"usage.poll":                  "check files for changes at this interval instead of using fsnotify",
+		"watch.start":                 "watching %d files. Press Ctrl+C to stop",
+		"watch.fallback_polling":      "fsnotify is unavailable, falling back to polling. err=%v",
+		"watch.added_func":            "%s: added %s",
+		"watch.added_cases":           "%[1]s: added test cases (%[3]s) to %[2]s",
+		"watch.removed_cases":         "%[1]s: the branches for test cases (%[3]s) in %[2]s no longer exist. The test cases were not removed",
+		"watch.unchanged":             "%s: no test cases added",
+		"watch.error":                 "%scould not update the test file. err=%v",
+		"usage.validate_args":         "[configuration file or directory to start searching from]",
+		"create.fallback":             "an error occurred while running tgen.\nfalling back to plain gotests. err=%+v\n",
+		"suggest_clock.existing":      "%[1]s: %[3]s in %[2]s can be replaced with the existing field %[5]s of %[4]s",
+		"suggest_clock.field":         "%[1]s: consider adding the field `%[5]s %[6]s` to %[4]s and injecting it in place of %[3]s in %[2]s",
+		"suggest_clock.param":         "%[1]s: consider receiving `%[5]s %[6]s` as a parameter in place of %[3]s in %[2]s",
+		"type_args.invalid":           "type argument (%s) must be in the form \"name=type\"",
+		"config.unknown_fields":       "%s: unknown keys: %v",
+		"config.invalid_regexp":       "%sinvalid regular expression for %s: %v",
+		"config.template_dir_missing": "%stemplate_dir (%s) is not an existing directory",
+		"config.invalid_mock_backend": "%smock_backend (%s) must be golang/mock or uber/mock",
+		"config.invalid_lang":         "%slang (%s) must be ja or en",
+		"config.invalid_assertion":    "%sassertion (%s) must be one of assert, require, cmp or std",
+		"config.path_missing":         "path of packages[%d] is not set",
+		"config.package_prefix":       "packages[%d](%s): ",
+		"config.not_found":            "configuration file not found",
+	},
+}
+
+// localize キーに対応するメッセージを、コマンドの表示に利用する言語で作成する
+func localize(key string, args ...interface{}) string {
+	format, ok := cliMessages[internal.NormalizeLang(cliLang)][key]
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// detectLang コマンドの表示に利用する言語を決める
+// 優先順位は、引数の--lang、カレントディレクトリから探した設定ファイルのlang、環境変数LANGの順
+func detectLang(args []string) string {
+	for i, arg := range args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != LangFlag {
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			value = args[i+1]
+		}
+		return internal.NormalizeLang(value)
+	}
+	if cfg, err := findConfig("."); err == nil && cfg != nil && cfg.Lang != "" {
+		return internal.NormalizeLang(cfg.Lang)
+	}
+	return langFromEnv()
+}
+
+// langFromEnv 環境変数LANG(例: en_US.UTF-8)から言語を決める
+// 対応していない言語の場合は日本語にする
+func langFromEnv() string {
+	lang, _, _ := strings.Cut(os.Getenv("LANG"), "_")
+	lang, _, _ = strings.Cut(lang, ".")
+	return internal.NormalizeLang(strings.ToLower(lang))
+}
@@ -0,0 +1,102 @@
+package subcmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/kazdevl/tgen"
+)
+
+// report 解析結果を機械的に扱うためのレポート(--reportでjsonとして出力する)
+type report struct {
+	Files []*fileReport
+}
+
+// fileReport テスト対象のファイルごとの解析結果
+type fileReport struct {
+	// テスト対象のファイルのパス
+	Path string
+	// 解析に失敗した場合のエラー(失敗した場合は、gotestsのみでテストコードを生成する)
+	Error *reportError
+	// 解析時に検出した内容(テストケースの生成の対象外とした構文を含む)
+	Diagnostics []*tgen.Diagnostic
+}
+
+// reportError 解析に失敗した場合のエラー
+type reportError struct {
+	// エラーの内容を表すメッセージのキー(tgen以外のエラーの場合は空文字)
+	Code    string
+	Message string
+	// エラーの原因となった位置(特定できない場合はゼロ値)
+	Position token.Position
+}
+
+// add テスト対象のファイルの解析結果を追加する
+func (r *report) add(path string, result *tgen.Result, err error) {
+	f := &fileReport{
+		Path:        path,
+		Diagnostics: []*tgen.Diagnostic{},
+	}
+	if result != nil && result.Params.Diagnostics != nil {
+		f.Diagnostics = result.Params.Diagnostics
+	}
+	if err != nil {
+		f.Error = &reportError{Message: err.Error()}
+		var tgenErr *tgen.Error
+		if errors.As(err, &tgenErr) {
+			f.Error.Code = tgenErr.Key
+			f.Error.Position = tgenErr.Position
+		}
+	}
+	r.Files = append(r.Files, f)
+}
+
+// write レポートをjsonとして出力する(pathが「-」の場合は標準出力に出力する)
+func (r *report) write(path string) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+// printClockSuggestions テスト対象の関数が呼び出している、実行ごとに結果が変わる関数ごとに、差し替えられるように注入するフィールドの候補を表示する(--suggest_clockの場合のみ)
+// 構造体が差し替えに利用できるフィールドを既に持っている場合は、そのフィールドを表示する
+func printClockSuggestions(w io.Writer, result *tgen.Result) {
+	if result == nil || result.Params == nil {
+		return
+	}
+	type suggestion struct {
+		method string
+		call   *tgen.NondeterministicCall
+	}
+	suggestions := make([]suggestion, 0)
+	for name, methodInfo := range result.Params.MethodInfoMap {
+		for _, call := range methodInfo.NondeterministicCalls {
+			suggestions = append(suggestions, suggestion{method: name, call: call})
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		return suggestions[i].call.Position.Offset < suggestions[j].call.Position.Offset
+	})
+	for _, s := range suggestions {
+		method, key := s.method, "suggest_clock.param"
+		if s.call.Struct != "" {
+			method, key = s.call.Struct+"."+s.method, "suggest_clock.field"
+			if s.call.Existing {
+				key = "suggest_clock.existing"
+			}
+		}
+		fmt.Fprintln(w, localize(key, s.call.Position, method, s.call.Func, s.call.Struct, s.call.Field, s.call.FieldType))
+	}
+}
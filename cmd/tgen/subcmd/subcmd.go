@@ -1,8 +1,14 @@
 package subcmd
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
 
+	"github.com/kazdevl/tgen"
 	"github.com/urfave/cli/v2"
 )
 
@@ -13,11 +19,32 @@ const (
 	TemplateDirFlag     = "template_dir"
 	PrintTestInputsFlag = "i"
 	ParallelFlag        = "parallel"
+	TypeArgsFlag        = "type_args"
+	LangFlag            = "lang"
+	StrictFlag          = "strict"
+	ReportFlag          = "report"
+	JobsFlag            = "jobs"
+	NoCacheFlag         = "no_cache"
+	UpdateFlag          = "update"
+	DebounceFlag        = "debounce"
+	PollFlag            = "poll"
+	AssertionFlag       = "assertion"
+	BenchFlag           = "bench"
+	FuzzFlag            = "fuzz"
+	SuggestClockFlag    = "suggest_clock"
 )
 
+// defaultTemplateDir テンプレートのディレクトリの初期値
+const defaultTemplateDir = "template"
+
 func ProvideSubCommands() cli.Commands {
+	cliLang = detectLang(os.Args[1:])
 	return cli.Commands{
 		generateCreateCommand(),
+		generateConfigCommand(),
+		generateLSPCommand(),
+		generateVetCommand(),
+		generateWatchCommand(),
 	}
 }
 
@@ -25,38 +52,135 @@ func ProvideSubCommands() cli.Commands {
 func getCommonFlags() []cli.Flag {
 	return []cli.Flag{
 		&cli.StringFlag{
-			Name: OnlyFlag, Usage: "指定した正規表現に合致する関数もしくはメソッドに対してテストを生成する",
+			Name: OnlyFlag, Usage: localize("usage.only"),
 		},
 		&cli.BoolFlag{
-			Name: ExportedFlag, Usage: "公開されている関数もしくはメソッドに対してテストを生成する。onlyよりも優先される", Value: false,
+			Name: ExportedFlag, Usage: localize("usage.exported"), Value: false,
 		},
 		&cli.StringFlag{
-			Name: ExclFlag, Usage: "指定した正規表現に合致しない関数もしくはメソッドに対してテストを生成する。onlyとexportedよりも優先される",
+			Name: ExclFlag, Usage: localize("usage.excl"),
 		},
 		&cli.StringFlag{
-			Name: TemplateDirFlag, Usage: "テストの生成に利用するテンプレートのディレクトリへのパス", Value: "template",
+			Name: TemplateDirFlag, Usage: localize("usage.template_dir"), Value: defaultTemplateDir,
 		},
 		&cli.BoolFlag{
-			Name: PrintTestInputsFlag, Usage: "エラーメッセージにテストの入力を出力するか", Value: true,
+			Name: PrintTestInputsFlag, Usage: localize("usage.i"), Value: true,
 		},
 		&cli.BoolFlag{
-			Name: ParallelFlag, Usage: "サブテストを並行実行するテストコードを出力する", Value: false,
+			Name: ParallelFlag, Usage: localize("usage.parallel"), Value: false,
+		},
+		&cli.StringFlag{
+			Name: TypeArgsFlag, Usage: localize("usage.type_args"),
+		},
+		&cli.StringFlag{
+			Name: ConfigFlag, Usage: localize("usage.config"),
+		},
+		&cli.StringFlag{
+			Name: LangFlag, Usage: localize("usage.lang"),
+		},
+		&cli.StringFlag{
+			Name: AssertionFlag, Usage: localize("usage.assertion"),
+		},
+		&cli.BoolFlag{
+			Name: BenchFlag, Usage: localize("usage.bench"), Value: false,
+		},
+		&cli.BoolFlag{
+			Name: FuzzFlag, Usage: localize("usage.fuzz"), Value: false,
+		},
+		&cli.BoolFlag{
+			Name: StrictFlag, Usage: localize("usage.strict"), Value: false,
+		},
+		&cli.StringFlag{
+			Name: ReportFlag, Usage: localize("usage.report"),
 		},
+		&cli.BoolFlag{
+			Name: SuggestClockFlag, Usage: localize("usage.suggest_clock"), Value: false,
+		},
+	}
+}
+
+// parseTypeArgs 「型パラメータ名=型」のカンマ区切りの文字列を解析する
+// 型にカンマが含まれる場合(例: map[K]func(int, string))を考慮し、括弧の外側のカンマのみで区切る
+func parseTypeArgs(src string) (map[string]string, error) {
+	typeArgs := map[string]string{}
+	if strings.TrimSpace(src) == "" {
+		return typeArgs, nil
+	}
+	depth, start := 0, 0
+	entries := make([]string, 0)
+	for i, r := range src {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				entries = append(entries, src[start:i])
+				start = i + 1
+			}
+		}
+	}
+	entries = append(entries, src[start:])
+	for _, entry := range entries {
+		name, typ, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(name) == "" || strings.TrimSpace(typ) == "" {
+			return nil, errors.New(localize("type_args.invalid", entry))
+		}
+		typeArgs[strings.TrimSpace(name)] = strings.TrimSpace(typ)
+	}
+	return typeArgs, nil
+}
+
+// generateOptions gotestsをライブラリとして呼び出す場合の、テストコードの生成のオプション
+func generateOptions(s *settings) ([]tgen.Option, error) {
+	opts := []tgen.Option{
+		tgen.WithLang(s.Lang),
+		tgen.WithExported(s.Exported),
+		tgen.WithPrintInputs(s.PrintInputs),
+		tgen.WithParallel(s.Parallel),
+	}
+	if s.Only != "" {
+		only, err := regexp.Compile(s.Only)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, tgen.WithOnly(only))
+	}
+	if s.Excl != "" {
+		excl, err := regexp.Compile(s.Excl)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, tgen.WithExcl(excl))
+	}
+	return opts, nil
+}
+
+// resolveTemplates gotestsをライブラリとして呼び出す場合のテンプレート
+// テンプレートのディレクトリが初期値のまま存在しない場合は、nil(埋め込まれたテンプレート)を返す
+func resolveTemplates(s *settings) (fs.FS, error) {
+	if info, err := os.Stat(s.TemplateDir); err == nil && info.IsDir() {
+		return os.DirFS(s.TemplateDir), nil
+	}
+	if s.TemplateDir != defaultTemplateDir {
+		return nil, errors.New(localize("config.template_dir_missing", "", s.TemplateDir))
 	}
+	return nil, nil
 }
 
-func createCommonFlagOptionsForGotests(cCtx *cli.Context) []string {
+func createCommonFlagOptionsForGotests(s *settings) []string {
 	options := []string{
 		"-w",
 		"-all",
-		fmt.Sprintf("-exported=%t", cCtx.Bool(ExportedFlag)),
-		fmt.Sprintf("-i=%t", cCtx.Bool(PrintTestInputsFlag)),
-		fmt.Sprintf("-parallel=%t", cCtx.Bool(ParallelFlag)),
+		fmt.Sprintf("-exported=%t", s.Exported),
+		fmt.Sprintf("-i=%t", s.PrintInputs),
+		fmt.Sprintf("-parallel=%t", s.Parallel),
 	}
-	if onlyFuncs := cCtx.String(OnlyFlag); onlyFuncs != "" {
+	if onlyFuncs := s.Only; onlyFuncs != "" {
 		options = append(options, fmt.Sprintf("-only=%s", onlyFuncs))
 	}
-	if exclFuncs := cCtx.String(ExclFlag); exclFuncs != "" {
+	if exclFuncs := s.Excl; exclFuncs != "" {
 		options = append(options, fmt.Sprintf("-excl=%s", exclFuncs))
 	}
 	return options
@@ -0,0 +1,31 @@
+package subcmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTypeArgs(t *testing.T) {
+	tests := map[string]map[string]string{
+		" ":                      {},
+		"T=int":                  {"T": "int"},
+		"K = string, V = []byte": {"K": "string", "V": "[]byte"},
+		"K=map[string]struct{ a, b int },F=func(a, b int) error": {"K": "map[string]struct{ a, b int }", "F": "func(a, b int) error"},
+	}
+	for src, want := range tests {
+		got, err := parseTypeArgs(src)
+		if err != nil {
+			t.Errorf("parseTypeArgs(%q) error = %v", src, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("parseTypeArgs(%q) = %v, want %v", src, got, want)
+		}
+	}
+	// 型・型パラメータ名・=が欠けている指定
+	for _, src := range []string{"T=", "T=int,=string", "int"} {
+		if _, err := parseTypeArgs(src); err == nil {
+			t.Errorf("parseTypeArgs(%q) error = nil, want an error", src)
+		}
+	}
+}
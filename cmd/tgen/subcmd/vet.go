@@ -0,0 +1,31 @@
+package subcmd
+
+import (
+	"os"
+
+	"github.com/kazdevl/tgen/analyzer"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func generateVetCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "vet",
+		Usage:     "report branches that have no corresponding test case in the existing _test.go",
+		ArgsUsage: "[-lang ja|en] [singlechecker flags] packages...",
+		// singlecheckerが自身でオプションを解析するため
+		SkipFlagParsing: true,
+		Action:          vetAction,
+	}
+}
+
+// vetAction singlecheckerでAnalyzerを実行する
+// singlecheckerはos.Argsを解析して終了コードを返すため、サブコマンド名を除いた引数に置き換えて呼び出す
+func vetAction(cCtx *cli.Context) error {
+	if err := analyzer.Analyzer.Flags.Set("lang", cliLang); err != nil {
+		return err
+	}
+	os.Args = append([]string{os.Args[0] + " vet"}, cCtx.Args().Slice()...)
+	singlechecker.Main(analyzer.Analyzer)
+	return nil
+}
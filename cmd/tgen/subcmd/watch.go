@@ -0,0 +1,622 @@
+package subcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/kazdevl/tgen"
+	"github.com/urfave/cli/v2"
+)
+
+func generateWatchCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "watch",
+		Aliases:   []string{"w"},
+		Usage:     "watch target files and add test cases for new branches on save",
+		ArgsUsage: localize("usage.watch_args"),
+		Action:    watchAction,
+		Flags:     getWatchFlags(),
+	}
+}
+
+// getWatchFlags ファイルの変更を監視する場合に利用するオプション
+// 解析に失敗した場合はgotestsのみで生成せずにエラーを表示するため、--strictは除く
+func getWatchFlags() []cli.Flag {
+	excluded := map[string]bool{
+		StrictFlag:       true,
+		ReportFlag:       true,
+		SuggestClockFlag: true,
+	}
+	flags := make([]cli.Flag, 0)
+	for _, flag := range getCommonFlags() {
+		if !excluded[flag.Names()[0]] {
+			flags = append(flags, flag)
+		}
+	}
+	return append(flags,
+		&cli.IntFlag{
+			Name: JobsFlag, Usage: localize("usage.jobs"), Value: 1,
+		},
+		&cli.DurationFlag{
+			Name: DebounceFlag, Usage: localize("usage.debounce"), Value: 500 * time.Millisecond,
+		},
+		&cli.DurationFlag{
+			Name: PollFlag, Usage: localize("usage.poll"),
+		},
+	)
+}
+
+// watchAction テスト対象のファイルの変更を監視し、変更されたファイルのテストコードを既存のテストファイルに反映する
+// 保存時に複数回の変更が通知されることがあるため、--debounceの間に変更がなくなってから反映する
+func watchAction(cCtx *cli.Context) error {
+	ctx, stop := signal.NotifyContext(cCtx.Context, os.Interrupt)
+	defer stop()
+
+	patterns := cCtx.Args().Slice()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	scope, err := newWatchScope(patterns)
+	if err != nil {
+		return err
+	}
+	files, err := scope.files()
+	if err != nil {
+		return err
+	}
+	w := &testWatcher{cCtx: cCtx, generatedCases: map[string]map[string][]string{}}
+	// 削除された分岐を検知するため、監視を始める時点の解析結果から生成されるテストケースを記録する
+	w.snapshot(ctx, files)
+
+	var watcher fileWatcher
+	if interval := cCtx.Duration(PollFlag); interval > 0 {
+		watcher = newPollingWatcher(scope, interval)
+	} else if watcher, err = newNotifyWatcher(scope); err != nil {
+		// inotifyの上限に達した場合など、fsnotifyが利用できない場合はポーリングで監視する
+		fmt.Println(localize("watch.fallback_polling", err))
+		watcher = newPollingWatcher(scope, time.Second)
+	}
+	defer watcher.Close()
+	fmt.Println(localize("watch.start", len(files)))
+
+	debounce := time.NewTimer(0)
+	<-debounce.C
+	changed := map[string]bool{}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case path, ok := <-watcher.Events():
+			if !ok {
+				return nil
+			}
+			if !scope.contains(path) {
+				continue
+			}
+			changed[path] = true
+			debounce.Reset(cCtx.Duration(DebounceFlag))
+		case err, ok := <-watcher.Errors():
+			if !ok {
+				return nil
+			}
+			fmt.Println(localize("watch.error", "", err))
+		case <-debounce.C:
+			paths := make([]string, 0, len(changed))
+			for path := range changed {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			changed = map[string]bool{}
+			w.update(ctx, paths)
+		}
+	}
+}
+
+// testWatcher 監視中のテスト対象のファイルごとの状態
+type testWatcher struct {
+	cCtx *cli.Context
+	// テスト対象のファイルごとの、テスト関数名ごとの前回生成したテストケース名
+	generatedCases map[string]map[string][]string
+}
+
+// snapshot テスト対象のファイルを解析し、生成されるテストケースを記録する(テストファイルは変更しない)
+func (w *testWatcher) snapshot(ctx context.Context, paths []string) {
+	targets, err := newCreateTargets(w.cCtx, paths, nil, true)
+	if err != nil {
+		fmt.Println(localize("watch.error", "", err))
+		return
+	}
+	runTargets(w.cCtx, targets, func(group []*createTarget) {
+		analyzeGroup(ctx, group, nil)
+	})
+	for _, t := range targets {
+		if t.analyzeErr != nil {
+			continue
+		}
+		opts, err := generateOptions(t.s)
+		if err != nil {
+			continue
+		}
+		templates, err := resolveTemplates(t.s)
+		if err != nil {
+			continue
+		}
+		if r, err := tgen.Update(t.result, templates, nil, opts...); err == nil {
+			w.generatedCases[t.path] = r.GeneratedCases
+		}
+	}
+}
+
+// update 変更されたテスト対象のファイルを解析し、既存のテストファイルに反映した内容を表示する
+// 解析に失敗した場合は、既存のテストファイルを変更せずにエラーを表示する
+func (w *testWatcher) update(ctx context.Context, paths []string) {
+	existingPaths := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			delete(w.generatedCases, path)
+			continue
+		}
+		existingPaths = append(existingPaths, path)
+	}
+	targets, err := newCreateTargets(w.cCtx, existingPaths, nil, true)
+	if err != nil {
+		fmt.Println(localize("watch.error", "", err))
+		return
+	}
+	runTargets(w.cCtx, targets, func(group []*createTarget) {
+		analyzeGroup(ctx, group, nil)
+		for _, t := range group {
+			if t.analyzeErr == nil {
+				t.generateErr = t.updateTestFile()
+			}
+		}
+	})
+
+	for _, t := range targets {
+		os.Stdout.Write(t.out.Bytes())
+		if err := t.analyzeErr; err != nil || t.generateErr != nil {
+			if err == nil {
+				err = t.generateErr
+			}
+			fmt.Println(localize("watch.error", t.path+": ", err))
+			continue
+		}
+		w.printSummary(t)
+		w.generatedCases[t.path] = t.updateResult.GeneratedCases
+	}
+}
+
+// printSummary 追加したテスト関数・テストケースと、前回から生成されなくなったテストケースを表示する
+// 生成されなくなったテストケースは、利用者が編集している可能性があるため削除しない
+func (w *testWatcher) printSummary(t *createTarget) {
+	r := t.updateResult
+	changed := printUpdateResult(os.Stdout, t.path, r)
+	previous := w.generatedCases[t.path]
+	for _, funcName := range sortedKeys(previous) {
+		current := map[string]bool{}
+		for _, name := range r.GeneratedCases[funcName] {
+			current[name] = true
+		}
+		removed := make([]string, 0)
+		for _, name := range previous[funcName] {
+			if !current[name] {
+				removed = append(removed, name)
+			}
+		}
+		if len(removed) != 0 {
+			fmt.Println(localize("watch.removed_cases", t.path, funcName, strings.Join(removed, ", ")))
+			changed = true
+		}
+	}
+	if !changed {
+		fmt.Println(localize("watch.unchanged", t.path))
+	}
+}
+
+// printUpdateResult 既存のテストファイルに追加したテスト関数・テストケースを表示する
+// 追加したものがない場合はfalseを返す
+func printUpdateResult(w io.Writer, path string, r *tgen.UpdateResult) bool {
+	for _, funcName := range r.AddedFuncs {
+		fmt.Fprintln(w, localize("watch.added_func", path, funcName))
+	}
+	for _, funcName := range sortedKeys(r.AddedCases) {
+		fmt.Fprintln(w, localize("watch.added_cases", path, funcName, strings.Join(r.AddedCases[funcName], ", ")))
+	}
+	return len(r.AddedFuncs) != 0 || len(r.AddedCases) != 0
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// watchScope 監視するディレクトリ・ファイルの範囲
+type watchScope struct {
+	// 配下のディレクトリも含めて監視するディレクトリ(./...の形式で指定されたもの)
+	recursiveDirs []string
+	// 直下のファイルのみを監視するディレクトリ
+	dirs map[string]bool
+	// 個別に指定されたファイル
+	targetFiles map[string]bool
+}
+
+// newWatchScope 引数のパターン(ディレクトリ・ディレクトリ/...・ファイル)から監視する範囲を作成する
+func newWatchScope(patterns []string) (*watchScope, error) {
+	s := &watchScope{dirs: map[string]bool{}, targetFiles: map[string]bool{}}
+	for _, pattern := range patterns {
+		if dir := strings.TrimSuffix(pattern, "..."); dir != pattern {
+			s.recursiveDirs = append(s.recursiveDirs, filepath.Clean(dir))
+			continue
+		}
+		info, err := os.Stat(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			s.dirs[filepath.Clean(pattern)] = true
+		} else {
+			s.targetFiles[filepath.Clean(pattern)] = true
+		}
+	}
+	return s, nil
+}
+
+// contains テスト対象のファイルか否か
+func (s *watchScope) contains(path string) bool {
+	path = filepath.Clean(path)
+	if s.targetFiles[path] {
+		return true
+	}
+	if !isWatchTargetFile(path) {
+		return false
+	}
+	dir := filepath.Dir(path)
+	if s.dirs[dir] {
+		return true
+	}
+	for _, root := range s.recursiveDirs {
+		if s.isUnder(root, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnder ディレクトリが、監視対象外のディレクトリを経由せずにrootの配下にあるか否か
+func (s *watchScope) isUnder(root, dir string) bool {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	if rel == "." {
+		return true
+	}
+	for _, name := range strings.Split(rel, string(filepath.Separator)) {
+		if isSkippedDir(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// watchDirs 監視するディレクトリの一覧
+func (s *watchScope) watchDirs() ([]string, error) {
+	seen := map[string]bool{}
+	dirs := make([]string, 0)
+	add := func(dir string) {
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	for dir := range s.dirs {
+		add(dir)
+	}
+	for path := range s.targetFiles {
+		add(filepath.Dir(path))
+	}
+	for _, root := range s.recursiveDirs {
+		err := walkWatchDirs(root, func(dir string) {
+			add(dir)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// files 監視する範囲のテスト対象のファイルの一覧
+func (s *watchScope) files() ([]string, error) {
+	dirs, err := s.watchDirs()
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if !entry.IsDir() && s.contains(path) {
+				files = append(files, path)
+			}
+		}
+	}
+	return files, nil
+}
+
+// walkWatchDirs root配下の監視対象のディレクトリを辿る
+func walkWatchDirs(root string, fn func(dir string)) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && isSkippedDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		fn(path)
+		return nil
+	})
+}
+
+// isSkippedDir go buildと同じく、パッケージのパターンに含めないディレクトリか否か
+func isSkippedDir(name string) bool {
+	return name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+// isWatchTargetFile テストコードの生成の対象になるファイルか否か
+// テストファイルと、自動生成されたファイル(mockなど)は対象外とする
+func isWatchTargetFile(path string) bool {
+	name := filepath.Base(path)
+	if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+		return false
+	}
+	return !isGeneratedFile(path)
+}
+
+// isGeneratedFile package句より前に「// Code generated ... DO NOT EDIT.」のコメントがあるか否か
+// 削除されたファイルなど読み込めない場合は、自動生成されたファイルではないとみなす
+func isGeneratedFile(path string) bool {
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return false
+	}
+	for _, group := range f.Comments {
+		if group.Pos() > f.Package {
+			break
+		}
+		for _, comment := range group.List {
+			if strings.HasPrefix(comment.Text, "// Code generated ") && strings.HasSuffix(comment.Text, " DO NOT EDIT.") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fileWatcher ファイルの変更を通知する
+type fileWatcher interface {
+	// Events 変更(作成・削除を含む)されたファイルのパス
+	Events() <-chan string
+	Errors() <-chan error
+	Close() error
+}
+
+// notifyWatcher fsnotifyでファイルの変更を検知する
+// fsnotifyはディレクトリを再帰的に監視しないため、作成されたディレクトリも監視に追加する
+type notifyWatcher struct {
+	scope   *watchScope
+	watcher *fsnotify.Watcher
+	events  chan string
+	errors  chan error
+	done    chan struct{}
+}
+
+func newNotifyWatcher(scope *watchScope) (*notifyWatcher, error) {
+	dirs, err := scope.watchDirs()
+	if err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+	w := &notifyWatcher{
+		scope:   scope,
+		watcher: watcher,
+		events:  make(chan string),
+		errors:  make(chan error),
+		done:    make(chan struct{}),
+	}
+	go w.loop()
+	return w, nil
+}
+
+func (w *notifyWatcher) loop() {
+	defer close(w.events)
+	defer close(w.errors)
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					w.addDir(event.Name)
+					continue
+				}
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+				continue
+			}
+			select {
+			case w.events <- event.Name:
+			case <-w.done:
+				return
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			select {
+			case w.errors <- err:
+			case <-w.done:
+				return
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// addDir 作成されたディレクトリが監視する範囲にある場合は、配下のディレクトリも含めて監視に追加する
+func (w *notifyWatcher) addDir(dir string) {
+	for _, root := range w.scope.recursiveDirs {
+		if !w.scope.isUnder(root, dir) {
+			continue
+		}
+		_ = walkWatchDirs(dir, func(dir string) {
+			_ = w.watcher.Add(dir)
+		})
+		return
+	}
+}
+
+func (w *notifyWatcher) Events() <-chan string { return w.events }
+
+func (w *notifyWatcher) Errors() <-chan error { return w.errors }
+
+func (w *notifyWatcher) Close() error {
+	close(w.done)
+	return w.watcher.Close()
+}
+
+// pollingWatcher 一定の間隔でファイルの更新日時とサイズを比較して、変更を検知する
+type pollingWatcher struct {
+	scope    *watchScope
+	interval time.Duration
+	events   chan string
+	errors   chan error
+	done     chan struct{}
+}
+
+// fileStamp ファイルの変更の検知に利用する情報
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func newPollingWatcher(scope *watchScope, interval time.Duration) *pollingWatcher {
+	w := &pollingWatcher{
+		scope:    scope,
+		interval: interval,
+		events:   make(chan string),
+		errors:   make(chan error),
+		done:     make(chan struct{}),
+	}
+	go w.loop()
+	return w
+}
+
+func (w *pollingWatcher) loop() {
+	defer close(w.events)
+	defer close(w.errors)
+	stamps, _ := w.stamps()
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-w.done:
+			return
+		}
+		current, err := w.stamps()
+		if err != nil {
+			select {
+			case w.errors <- err:
+				continue
+			case <-w.done:
+				return
+			}
+		}
+		changed := make([]string, 0)
+		for path, stamp := range current {
+			if previous, ok := stamps[path]; !ok || previous != stamp {
+				changed = append(changed, path)
+			}
+		}
+		for path := range stamps {
+			if _, ok := current[path]; !ok {
+				changed = append(changed, path)
+			}
+		}
+		stamps = current
+		sort.Strings(changed)
+		for _, path := range changed {
+			select {
+			case w.events <- path:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// stamps 監視する範囲のテスト対象のファイルごとの、更新日時とサイズ
+func (w *pollingWatcher) stamps() (map[string]fileStamp, error) {
+	files, err := w.scope.files()
+	if err != nil {
+		return nil, err
+	}
+	stamps := make(map[string]fileStamp, len(files))
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	return stamps, nil
+}
+
+func (w *pollingWatcher) Events() <-chan string { return w.events }
+
+func (w *pollingWatcher) Errors() <-chan error { return w.errors }
+
+func (w *pollingWatcher) Close() error {
+	close(w.done)
+	return nil
+}
@@ -0,0 +1,36 @@
+package tgen
+
+import "github.com/kazdevl/tgen/internal"
+
+// Error 解析に失敗した場合のエラー
+// エラーの原因となった位置(Position)と、内容を表すメッセージのキー(Key)を持つ
+type Error = internal.Error
+
+// 解析に失敗した原因ごとのエラー
+// errors.Is(err, tgen.ErrAmbiguousReceiver)のように、解析に失敗した原因を判定できる
+var (
+	// ErrPackageNotLoaded テスト対象のファイルのパッケージを一つに特定できない
+	ErrPackageNotLoaded = internal.ErrPackageNotLoaded
+	// ErrFileNotLoaded テスト対象のファイルの構文木を読み取れない
+	ErrFileNotLoaded = internal.ErrFileNotLoaded
+	// ErrAmbiguousReceiver テスト対象のファイルに、複数の構造体のメソッドが含まれている
+	ErrAmbiguousReceiver = internal.ErrAmbiguousReceiver
+	// ErrStructNotFound テスト対象のメソッドを持つ構造体の定義を読み取れない
+	ErrStructNotFound = internal.ErrStructNotFound
+	// ErrNotStruct テスト対象のメソッドのレシーバーの型が構造体ではない
+	ErrNotStruct = internal.ErrNotStruct
+	// ErrUnsupportedRecv 対応していない形式のレシーバーである
+	ErrUnsupportedRecv = internal.ErrUnsupportedRecv
+	// ErrInvalidTypeArg 型パラメータに指定した型を利用できない
+	ErrInvalidTypeArg = internal.ErrInvalidTypeArg
+	// ErrTemplatesNotFound テストコードの生成に利用するテンプレートが見つからない
+	ErrTemplatesNotFound = internal.ErrTemplatesNotFound
+)
+
+// Diagnosticの重要度
+const (
+	// SeverityWarning 生成されるテストコードを利用者が修正する必要がある内容
+	SeverityWarning = internal.SeverityWarning
+	// SeverityInfo 仕様としてテストケースの生成の対象外とした構文
+	SeverityInfo = internal.SeverityInfo
+)
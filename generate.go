@@ -0,0 +1,155 @@
+package tgen
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cweill/gotests"
+	"github.com/kazdevl/tgen/internal"
+)
+
+//go:embed template/*.tmpl
+var defaultTemplates embed.FS
+
+// generateMu gotestsはテンプレートをパッケージ変数で保持しているため、テストコードの生成を同時に行わないようにする
+var generateMu sync.Mutex
+
+// DefaultTemplates このリポジトリのtemplateディレクトリのテンプレート一覧を返す
+func DefaultTemplates() fs.FS {
+	templates, err := fs.Sub(defaultTemplates, "template")
+	if err != nil {
+		// 埋め込んだディレクトリは必ず存在するため
+		panic(err)
+	}
+	return templates
+}
+
+// Generate 解析結果とテンプレートを使って、テストコードを生成する
+// gotestsをコマンドとして呼び出さずに生成し、ファイルへの書き込みは行わない
+// templates: 直下の*.tmplをテンプレートとして利用する(nilの場合はDefaultTemplatesを利用する)
+// AnalyzeSourceで解析した場合は、メモリ上のソースコードからテストコードを生成する
+// WithRegenerateが指定されている場合は、既存のテストファイルを参照せずに生成する
+// 生成するテストがない場合(既に全てのテストが存在する場合など)はnilを返す
+func Generate(result *Result, templates fs.FS) (output []byte, err error) {
+	if result == nil {
+		return nil, internal.NewError("error.result_nil")
+	}
+	o := result.opts
+	if o == nil {
+		o = newOptions(nil)
+	}
+	if templates == nil {
+		templates = DefaultTemplates()
+	}
+	templateData, err := readTemplates(templates)
+	if err != nil {
+		internal.SetErrorLang(err, o.lang)
+		return nil, err
+	}
+	// テンプレートからはjsonを経由した値(数値はfloat64)として参照されるため、gotestsのコマンドと同じくjsonを経由させる
+	b, err := json.Marshal(result.Params)
+	if err != nil {
+		return nil, err
+	}
+	var templateParams map[string]interface{}
+	if err := json.Unmarshal(b, &templateParams); err != nil {
+		return nil, err
+	}
+
+	generateMu.Lock()
+	defer generateMu.Unlock()
+	defer func() {
+		// gotestsはテンプレートの解析に失敗した場合にpanicするため
+		if r := recover(); r != nil {
+			output, err = nil, fmt.Errorf("gotests: %v", r)
+		}
+	}()
+	srcPath := result.Path
+	if result.content != nil || o.regenerate {
+		// gotestsはディスク上のファイルを読み込み、同じディレクトリのテストファイルにあるテストを除くため
+		// メモリ上のソースコードや、既存のテストファイルを除いたソースコードを一時ディレクトリに書き出す
+		content := result.content
+		if content == nil {
+			if content, err = os.ReadFile(result.Path); err != nil {
+				return nil, err
+			}
+		}
+		tmpDir, err := os.MkdirTemp("", "tgen")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmpDir)
+		srcPath, err = writeSourceForGotests(tmpDir, result.Path, content, !o.regenerate)
+		if err != nil {
+			return nil, err
+		}
+	}
+	generated, err := gotests.GenerateTests(srcPath, &gotests.Options{
+		Only:           o.only,
+		Exclude:        o.excl,
+		Exported:       o.exported,
+		PrintInputs:    o.printInputs,
+		Subtests:       true,
+		Parallel:       o.parallel,
+		TemplateParams: templateParams,
+		TemplateData:   templateData,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(generated) == 0 {
+		return nil, nil
+	}
+	return generated[0].Output, nil
+}
+
+// writeSourceForGotests メモリ上のソースコードを、同じファイル名で一時ディレクトリに書き出す
+// withTests: 既存のテストコードがある場合に、生成済みのテストを除くためにそれも書き出すか
+func writeSourceForGotests(tmpDir, path string, content []byte, withTests bool) (string, error) {
+	srcPath := filepath.Join(tmpDir, filepath.Base(path))
+	if err := os.WriteFile(srcPath, content, 0644); err != nil {
+		return "", err
+	}
+	if !withTests {
+		return srcPath, nil
+	}
+	testPath := strings.TrimSuffix(path, ".go") + "_test.go"
+	testContent, err := os.ReadFile(testPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return srcPath, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, filepath.Base(testPath)), testContent, 0644); err != nil {
+		return "", err
+	}
+	return srcPath, nil
+}
+
+// readTemplates 直下の*.tmplの内容を読み込む
+func readTemplates(templates fs.FS) ([][]byte, error) {
+	names, err := fs.Glob(templates, "*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, internal.NewError("error.templates_not_found")
+	}
+	templateData := make([][]byte, 0, len(names))
+	for _, name := range names {
+		data, err := fs.ReadFile(templates, name)
+		if err != nil {
+			return nil, err
+		}
+		templateData = append(templateData, data)
+	}
+	return templateData, nil
+}
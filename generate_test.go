@@ -0,0 +1,84 @@
+package tgen
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	// testdata/goldenのテストコードが利用する(go mod tidyでgo.modから削除されないようにする)
+	_ "github.com/DATA-DOG/go-sqlmock"
+	_ "github.com/golang/mock/gomock"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// TestGenerate_golden 生成したテストコードがgoldenファイルと一致し、実行できることを確認する
+// goldenファイルはテスト対象のパッケージの_test.goとして置き、go vetとgo testを実行できることを確認する
+// 生成するテストコードを変更した場合は、go test -run TestGenerate_golden -updateでgoldenファイルを更新する
+func TestGenerate_golden(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		golden string
+		opts   []Option
+	}{
+		{
+			name:   "mocked fields, mocked args, bench and fuzz",
+			src:    "testdata/golden/golden.go",
+			golden: "testdata/golden/golden_test.go",
+			opts:   []Option{WithLang("en"), WithAssertion("std"), WithBench(true), WithFuzz(true), WithRegenerate(true)},
+		},
+		{
+			name:   "sqlmock fields and bench",
+			src:    "testdata/golden/store.go",
+			golden: "testdata/golden/store_test.go",
+			opts:   []Option{WithLang("en"), WithAssertion("std"), WithBench(true), WithRegenerate(true)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Analyze(context.Background(), tt.src, tt.opts...)
+			if err != nil {
+				t.Fatalf("Analyze(%q) error = %v", tt.src, err)
+			}
+			got, err := Generate(result, nil)
+			if err != nil {
+				t.Fatalf("Generate(%q) error = %v", tt.src, err)
+			}
+			if *update {
+				if err := os.WriteFile(tt.golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(tt.golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("Generate(%q) does not match %s (run with -update to update it)\n%s", tt.src, tt.golden, got)
+			}
+			assertRuns(t, tt.src)
+		})
+	}
+}
+
+// assertRuns テスト対象のファイルのパッケージに対して、go vetとgo testを実行する
+// goldenファイルのテストケースはTODOの値を埋めていないため失敗するが、go vetの指摘がなく、panicせずに最後まで実行できることを確認する
+func assertRuns(t *testing.T, src string) {
+	t.Helper()
+	pkg := "./" + filepath.ToSlash(filepath.Dir(src))
+	if out, err := exec.Command("go", "vet", pkg).CombinedOutput(); err != nil {
+		t.Errorf("go vet %s error = %v\n%s", pkg, err, out)
+	}
+	out, err := exec.Command("go", "test", "-count=1", pkg).CombinedOutput()
+	if err != nil && !bytes.Contains(out, []byte("--- FAIL")) {
+		t.Errorf("go test %s error = %v\n%s", pkg, err, out)
+	}
+	if bytes.Contains(out, []byte("panic:")) {
+		t.Errorf("go test %s panicked\n%s", pkg, out)
+	}
+}
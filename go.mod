@@ -1,16 +1,23 @@
 module github.com/kazdevl/tgen
 
-go 1.19
+go 1.25.0
 
 require (
+	github.com/BurntSushi/toml v1.2.1
+	github.com/DATA-DOG/go-sqlmock v1.5.2
+	github.com/cweill/gotests v1.6.0
+	github.com/fsnotify/fsnotify v1.6.0
+	github.com/golang/mock v1.6.0
 	github.com/urfave/cli/v2 v2.23.5
-	golang.org/x/tools v0.3.0
+	golang.org/x/mod v0.37.0
+	golang.org/x/tools v0.47.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/cpuguy83/go-md2man/v2 v2.0.2 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/xrash/smetrics v0.0.0-20201216005158-039620a65673 // indirect
-	golang.org/x/mod v0.7.0 // indirect
-	golang.org/x/sys v0.2.0 // indirect
+	golang.org/x/sync v0.21.0 // indirect
+	golang.org/x/sys v0.46.0 // indirect
 )
@@ -0,0 +1,294 @@
+package internal
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// ifBranch テストケースの分岐点となるif文の情報
+type ifBranch struct {
+	// if文の位置
+	pos token.Pos
+	// 条件式を文字列にしたもの(例: len(name) != len(updateName))
+	condition string
+	// 条件式から作成するテストケース名のメッセージのキーと、書式に渡す値(キーが空の場合は条件式をそのまま利用する)
+	nameKey  string
+	nameArgs []interface{}
+	// 分岐に入った場合に期待するエラー
+	wantErr *WantErr
+	// テスト対象の関数がHTTPハンドラーの場合の、分岐に入った場合に期待するステータスコード
+	status string
+	// テスト対象のメソッドがgRPCのサービスのメソッドの場合の、分岐に入った場合に期待するステータスコード
+	code string
+	// 分岐に入るため(take)と、分岐に入らないため(avoid)に、mock化するメソッドが返す値
+	take, avoid mockReturns
+	// コンテキストがキャンセルされた場合に入る分岐か(例: if ctx.Err() != nil)
+	checksCancel bool
+	// 条件式がmock化するメソッドの戻り値に依存しているが、分岐に入るために返す値を決められなかったか
+	notForced bool
+}
+
+// newIfBranch if文の条件式から、テストケースの分岐点の情報を作成する
+func (r *depResolver) newIfBranch(src *ast.IfStmt) *ifBranch {
+	if assignStmt, ok := src.Init.(*ast.AssignStmt); ok {
+		// if err := s.f(); err != nil {} の形式の場合、初期化文はif文の後に辿られるため先に記録する
+		r.registerCallResults(assignStmt.Lhs, assignStmt.Rhs)
+	}
+	b := &ifBranch{
+		pos:       src.Pos(),
+		condition: types.ExprString(src.Cond),
+	}
+	b.take, b.avoid = r.conditionReturns(src.Cond)
+	var errMockCall token.Pos
+	b.wantErr, errMockCall = r.branchWantErr(src.Body)
+	if b.wantErr.Kind == WantErrMock {
+		// 条件式から決まらない場合も、期待するエラーをmockから返す
+		b.take = mergeMockReturns(mockReturns{errMockCall: {b.wantErr.index: MockErrName}}, b.take)
+	}
+	b.status = r.branchStatus(src.Body)
+	b.code = r.branchCode(src.Body, b.wantErr)
+	b.nameKey, b.nameArgs = r.describeCondition(src.Cond)
+	b.checksCancel = r.checksCancel(src)
+	b.notForced = b.take == nil && r.dependsOnMock(src.Cond)
+	return b
+}
+
+// dependsOnMock 式がmock化するメソッドの戻り値もしくは呼び出しを含むか否か
+func (r *depResolver) dependsOnMock(src ast.Expr) bool {
+	if r.info == nil {
+		return false
+	}
+	found := false
+	ast.Inspect(src, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.Ident:
+			if _, ok := r.mockResults[r.info.Uses[node]]; ok {
+				found = true
+			}
+		case *ast.CallExpr:
+			if depMethod, ok := r.resolve(node); ok {
+				_, found = depMethod.(*MockMethod)
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// checksCancel if文がコンテキストのキャンセルを確認し、キャンセルされた場合に分岐に入るか否か
+// キャンセルされていない場合に入る分岐(例: if ctx.Err() == nil)は対象外とする
+func (r *depResolver) checksCancel(src *ast.IfStmt) bool {
+	if r.contextParam == "" {
+		return false
+	}
+	if binaryExpr, ok := astutil.Unparen(src.Cond).(*ast.BinaryExpr); ok && binaryExpr.Op == token.EQL {
+		return false
+	}
+	found := false
+	for _, node := range []ast.Node{src.Init, src.Cond} {
+		if node == nil {
+			continue
+		}
+		ast.Inspect(node, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok && r.isContextCancelCheck(call) {
+				found = true
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// branchWantErr if文の分岐に入った場合に期待するエラーを、分岐の最後のreturn文の最後の戻り値から決める
+// 期待するエラーがmock化するメソッドの戻り値の場合は、そのメソッドの呼び出し式の位置も返す
+func (r *depResolver) branchWantErr(src *ast.BlockStmt) (*WantErr, token.Pos) {
+	if len(src.List) == 0 {
+		return &WantErr{Kind: WantErrAny}, token.NoPos
+	}
+	returnStmt, ok := src.List[len(src.List)-1].(*ast.ReturnStmt)
+	if !ok || len(returnStmt.Results) == 0 {
+		return &WantErr{Kind: WantErrAny}, token.NoPos
+	}
+	return r.expectedErr(returnStmt.Results[len(returnStmt.Results)-1])
+}
+
+// expectedErr return文で返すerrorの式から、期待するエラーを決める
+// nil: エラーを返さない
+// パッケージ変数(例: ErrNotFound, sql.ErrNoRows): errors.Isで比較する
+// errors.New("...")・fmt.Errorf("...")(書式の指定なし): エラーメッセージで比較する
+// mock化するメソッドが返したerror: mockが返すテスト用のエラーとerrors.Isで比較する
+// fmt.Errorf("...: %w", err): ラップしたエラーで比較する
+func (r *depResolver) expectedErr(src ast.Expr) (*WantErr, token.Pos) {
+	switch expr := astutil.Unparen(src).(type) {
+	case *ast.Ident:
+		if expr.Name == "nil" && (r.info == nil || r.info.Uses[expr] == types.Universe.Lookup("nil")) {
+			return &WantErr{Kind: WantErrNone}, token.NoPos
+		}
+		if r.info == nil {
+			break
+		}
+		obj := r.info.Uses[expr]
+		if isSentinelErr(obj) {
+			return &WantErr{Kind: WantErrSentinel, Value: expr.Name}, token.NoPos
+		}
+		if result, ok := r.mockResults[obj]; ok && isErrorType(obj.Type()) {
+			return &WantErr{Kind: WantErrMock, Value: MockErrName, index: result.index}, result.call
+		}
+	case *ast.SelectorExpr:
+		if r.info != nil && isSentinelErr(r.info.Uses[expr.Sel]) {
+			return &WantErr{Kind: WantErrSentinel, Value: types.ExprString(expr)}, token.NoPos
+		}
+	case *ast.CallExpr:
+		if r.info == nil || len(expr.Args) == 0 {
+			break
+		}
+		fun, ok := astutil.Unparen(expr.Fun).(*ast.SelectorExpr)
+		if !ok {
+			break
+		}
+		callee, ok := r.info.Uses[fun.Sel].(*types.Func)
+		if !ok || callee.Pkg() == nil {
+			break
+		}
+		format, isLit := astutil.Unparen(expr.Args[0]).(*ast.BasicLit)
+		switch {
+		case callee.Pkg().Path() == "errors" && callee.Name() == "New" && isLit && format.Kind == token.STRING:
+			return &WantErr{Kind: WantErrMessage, Value: format.Value}, token.NoPos
+		case callee.Pkg().Path() == "fmt" && callee.Name() == "Errorf" && isLit && format.Kind == token.STRING:
+			if len(expr.Args) == 1 && !strings.Contains(format.Value, "%") {
+				return &WantErr{Kind: WantErrMessage, Value: format.Value}, token.NoPos
+			}
+			if !strings.Contains(format.Value, "%w") {
+				break
+			}
+			for _, arg := range expr.Args[1:] {
+				if wantErr, pos := r.expectedErr(arg); wantErr.Kind == WantErrSentinel || wantErr.Kind == WantErrMock {
+					return wantErr, pos
+				}
+			}
+		}
+	}
+	return &WantErr{Kind: WantErrAny}, token.NoPos
+}
+
+// isSentinelErr パッケージ変数として定義されたerror(センチネルエラー)か否か
+func isSentinelErr(src types.Object) bool {
+	v, ok := src.(*types.Var)
+	if !ok || v.Pkg() == nil || v.Parent() != v.Pkg().Scope() {
+		return false
+	}
+	errorType := types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+	return types.Implements(v.Type(), errorType)
+}
+
+// describeCondition 条件式を、テストケース名のメッセージのキーと書式に渡す値で表す
+// 表せない条件式の場合は空のキーを返す
+func (r *depResolver) describeCondition(src ast.Expr) (string, []interface{}) {
+	switch expr := astutil.Unparen(src).(type) {
+	case *ast.CallExpr:
+		return "testcase.cond.true", []interface{}{r.calleeName(expr)}
+	case *ast.Ident:
+		return "testcase.cond.true", []interface{}{expr.Name}
+	case *ast.UnaryExpr:
+		if expr.Op != token.NOT {
+			return "", nil
+		}
+		switch x := astutil.Unparen(expr.X).(type) {
+		case *ast.CallExpr:
+			return "testcase.cond.false", []interface{}{r.calleeName(x)}
+		case *ast.Ident:
+			return "testcase.cond.false", []interface{}{x.Name}
+		}
+	case *ast.BinaryExpr:
+		if expr.Op != token.NEQ {
+			return "", nil
+		}
+		x, ok := astutil.Unparen(expr.X).(*ast.Ident)
+		if !ok || r.info == nil {
+			return "", nil
+		}
+		if y, ok := astutil.Unparen(expr.Y).(*ast.Ident); !ok || y.Name != "nil" {
+			return "", nil
+		}
+		if callee, ok := r.errSources[r.info.Uses[x]]; ok {
+			return "testcase.cond.returns_error", []interface{}{callee}
+		}
+	}
+	return "", nil
+}
+
+// checksMockErr if文がmock化するメソッドの返したerrorを確認しているか(例: if err != nil {})
+func (r *depResolver) checksMockErr(src *ast.IfStmt) bool {
+	if assignStmt, ok := src.Init.(*ast.AssignStmt); ok {
+		r.registerCallResults(assignStmt.Lhs, assignStmt.Rhs)
+	}
+	binaryExpr, ok := astutil.Unparen(src.Cond).(*ast.BinaryExpr)
+	if !ok || r.info == nil {
+		return false
+	}
+	x, ok := astutil.Unparen(binaryExpr.X).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	obj := r.info.Uses[x]
+	_, ok = r.mockResults[obj]
+	return ok && isErrorType(obj.Type())
+}
+
+// registerCallResults 呼び出し式の戻り値をローカル変数に代入している場合、その変数と呼び出し先を記録する
+// errorの戻り値はテストケース名に利用する呼び出し先の名前を、mock化するメソッドの戻り値は呼び出し式の位置と何番目の戻り値かを記録する
+func (r *depResolver) registerCallResults(lhs []ast.Expr, rhs []ast.Expr) {
+	if r.info == nil || len(rhs) != 1 {
+		return
+	}
+	callExpr, ok := astutil.Unparen(rhs[0]).(*ast.CallExpr)
+	if !ok {
+		return
+	}
+	depMethod, _ := r.resolve(callExpr)
+	_, isMock := depMethod.(*MockMethod)
+	for i, expr := range lhs {
+		ident, ok := expr.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		obj := r.info.Defs[ident]
+		if obj == nil {
+			obj = r.info.Uses[ident]
+		}
+		if obj == nil {
+			continue
+		}
+		if isErrorType(obj.Type()) {
+			r.errSources[obj] = r.calleeName(callExpr)
+		}
+		if isMock {
+			r.mockResults[obj] = &mockResult{call: callExpr.Pos(), index: i}
+			continue
+		}
+		delete(r.mockResults, obj)
+	}
+}
+
+// calleeName テストケース名に利用する、呼び出し先の名前
+// テスト対象のメソッドとmock化するメソッドはメソッド名のみ、それ以外は呼び出し先の式(例: strconv.Atoi, t.After)にする
+func (r *depResolver) calleeName(src *ast.CallExpr) string {
+	fun, ok := astutil.Unparen(src.Fun).(*ast.SelectorExpr)
+	if !ok || r.info == nil {
+		return types.ExprString(src.Fun)
+	}
+	if x, ok := astutil.Unparen(fun.X).(*ast.Ident); ok && r.recv != nil && r.info.Uses[x] == r.recv {
+		return fun.Sel.Name
+	}
+	if mockMethod, ok := r.resolveRowScan(fun); ok {
+		return mockMethod.Name
+	}
+	if _, ok := r.resolveMethodValue(fun); ok {
+		return fun.Sel.Name
+	}
+	return types.ExprString(fun)
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"errors"
+	"go/token"
+)
+
+// 解析に失敗した原因ごとのエラー
+// errors.Isで、解析に失敗したエラーがどの原因によるものかを判定できる
+var (
+	// ErrPackageNotLoaded テスト対象のファイルのパッケージを一つに特定できない
+	ErrPackageNotLoaded = &Error{Key: "error.pkgs_not_one"}
+	// ErrFileNotLoaded テスト対象のファイルの構文木を読み取れない
+	ErrFileNotLoaded = &Error{Key: "error.syntax_not_found"}
+	// ErrAmbiguousReceiver テスト対象のファイルに、複数の構造体のメソッドが含まれている
+	ErrAmbiguousReceiver = &Error{Key: "error.struct_not_unique"}
+	// ErrStructNotFound テスト対象のメソッドを持つ構造体の定義を読み取れない
+	ErrStructNotFound = &Error{Key: "error.struct_not_found"}
+	// ErrNotStruct テスト対象のメソッドのレシーバーの型が構造体ではない
+	ErrNotStruct = &Error{Key: "error.not_struct"}
+	// ErrUnsupportedRecv 対応していない形式のレシーバーである
+	ErrUnsupportedRecv = &Error{Key: "error.unexpected_format"}
+	// ErrInvalidTypeArg 型パラメータに指定した型を利用できない
+	ErrInvalidTypeArg = &Error{Key: "error.type_arg_invalid"}
+	// ErrTemplatesNotFound テストコードの生成に利用するテンプレートが見つからない
+	ErrTemplatesNotFound = &Error{Key: "error.templates_not_found"}
+)
+
+// errorKinds メッセージのキーと、そのエラーの原因の組み合わせ
+var errorKinds = map[string]*Error{
+	"error.pkgs_not_one":        ErrPackageNotLoaded,
+	"error.syntax_not_found":    ErrFileNotLoaded,
+	"error.struct_not_unique":   ErrAmbiguousReceiver,
+	"error.scope_not_found":     ErrStructNotFound,
+	"error.struct_not_found":    ErrStructNotFound,
+	"error.not_struct":          ErrNotStruct,
+	"error.instance_not_struct": ErrNotStruct,
+	"error.unexpected_format":   ErrUnsupportedRecv,
+	"error.type_arg_invalid":    ErrInvalidTypeArg,
+	"error.type_arg_not_type":   ErrInvalidTypeArg,
+	"error.type_arg_unresolved": ErrInvalidTypeArg,
+	"error.templates_not_found": ErrTemplatesNotFound,
+}
+
+// Error 言語を切り替えて表示できるエラー
+type Error struct {
+	// 表示する言語
+	Lang string
+	// メッセージのキー(エラーの内容を機械的に判別する場合に利用する)
+	Key string
+	// メッセージの書式に渡す値
+	Args []interface{}
+	// エラーの原因となった位置(特定できない場合はゼロ値)
+	Position token.Position
+}
+
+// NewError 言語を切り替えて表示できるエラーを作成する
+func NewError(key string, args ...interface{}) *Error {
+	return &Error{Key: key, Args: args}
+}
+
+// at エラーの原因となった位置を設定する
+func (e *Error) at(position token.Position) *Error {
+	e.Position = position
+	return e
+}
+
+func (e *Error) Error() string {
+	message := Localize(e.Lang, e.Key, e.Args...)
+	if !e.Position.IsValid() {
+		return message
+	}
+	return e.Position.String() + ": " + message
+}
+
+// Is エラーの原因が一致するか否か
+func (e *Error) Is(target error) bool {
+	kind, ok := errorKinds[e.Key]
+	return ok && kind == target
+}
+
+// Unwrap 書式に渡した値のうち、エラーのものを返す
+func (e *Error) Unwrap() error {
+	for _, arg := range e.Args {
+		if err, ok := arg.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetErrorLang エラーがErrorの場合に、表示する言語を設定する
+func SetErrorLang(err error, lang string) {
+	var e *Error
+	if errors.As(err, &e) {
+		e.Lang = lang
+	}
+}
+
+// setErrorPosition エラーがErrorで位置が設定されていない場合に、エラーの原因となった位置を設定する
+func setErrorPosition(err error, position token.Position) {
+	var e *Error
+	if errors.As(err, &e) && !e.Position.IsValid() {
+		e.Position = position
+	}
+}
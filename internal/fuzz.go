@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+)
+
+// ParamInfo テスト対象の関数の引数の情報
+type ParamInfo struct {
+	// テンプレートにおける引数名(名前がない引数はgotestsと同じくin+位置)
+	Name string
+	// パッケージ名で修飾した型(例: UserID, []byte)
+	Type string
+	// fuzzテストで値を生成できる型(testing.F.Addに渡せる型, 例: string, []byte, int64)
+	// 生成できない型の場合は空文字
+	Kind string
+	// シードコーパスに追加する、Kindのゼロ値の式(例: "", 0, int64(0), []byte(""))
+	Seed string
+}
+
+// methodParams テスト対象の関数の引数の情報と、fuzzテストを生成できるか否かを返す
+// context.Contextとmock化する引数以外の全ての引数の値をfuzzテストで生成できる場合のみ、fuzzテストを生成できる
+func (r *depResolver) methodParams(src *ast.FuncDecl) ([]*ParamInfo, bool) {
+	if r.info == nil {
+		return nil, false
+	}
+	params := make([]*ParamInfo, 0, src.Type.Params.NumFields())
+	fuzzable, hasKind := true, false
+	index := 0
+	for _, field := range src.Type.Params.List {
+		t := r.info.TypeOf(field.Type)
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{nil}
+		}
+		for _, name := range names {
+			param := &ParamInfo{Name: fmt.Sprintf("in%d", index)}
+			if name != nil && name.Name != "_" {
+				param.Name = name.Name
+			}
+			index++
+			if t == nil {
+				return nil, false
+			}
+			param.Type = types.TypeString(t, packageQualifier(r.pkg))
+			param.Kind, param.Seed = fuzzKind(t)
+			params = append(params, param)
+			if _, isMock := r.argFieldMap[param.Name]; isMock || param.Name == r.contextParam {
+				continue
+			}
+			if param.Kind == "" {
+				fuzzable = false
+				continue
+			}
+			hasKind = true
+		}
+	}
+	return params, fuzzable && hasKind
+}
+
+// fuzzKind fuzzテストで値を生成できる型(文字列・[]byte・整数・浮動小数点数・真偽値を基にした型)の場合に、その型とゼロ値の式を返す
+func fuzzKind(src types.Type) (string, string) {
+	switch u := src.Underlying().(type) {
+	case *types.Basic:
+		switch u.Kind() {
+		case types.String:
+			return "string", `""`
+		case types.Bool:
+			return "bool", "false"
+		case types.Int:
+			return "int", "0"
+		case types.Int8, types.Int16, types.Int32, types.Int64,
+			types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64,
+			types.Float32, types.Float64:
+			return u.Name(), u.Name() + "(0)"
+		}
+	case *types.Slice:
+		if types.Identical(u.Elem(), types.Typ[types.Byte]) {
+			return "[]byte", `[]byte("")`
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,247 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// typeArgResolver 型パラメータを、テストで利用する具体的な型に置き換える
+type typeArgResolver struct {
+	fset *token.FileSet
+	// テスト対象のパッケージ
+	pkg *types.Package
+	// 型引数の式を評価する位置(テスト対象ファイルのimportを解決するため)
+	pos token.Pos
+	// 利用者が指定した型パラメータ名と型の組み合わせ
+	typeArgs map[string]string
+	// 制約から型を選択した型パラメータ名(同じ内容を何度も伝えないため)
+	reported map[string]bool
+	// 置き換え時に検出した、利用者に伝えるべき内容
+	diagnostics []*Diagnostic
+}
+
+func newTypeArgResolver(fset *token.FileSet, pkg *types.Package, astF *ast.File, typeArgs map[string]string) *typeArgResolver {
+	return &typeArgResolver{
+		fset:     fset,
+		pkg:      pkg,
+		pos:      astF.Name.Pos(),
+		typeArgs: typeArgs,
+		reported: map[string]bool{},
+	}
+}
+
+// resolve 型パラメータごとに具体的な型を決める
+// 利用者が指定していない型パラメータは、制約から型を選択する
+func (r *typeArgResolver) resolve(tparams *types.TypeParamList) ([]types.Type, error) {
+	results := make([]types.Type, 0, tparams.Len())
+	for i := 0; i < tparams.Len(); i++ {
+		tparam := tparams.At(i)
+		name := tparam.Obj().Name()
+		if expr, ok := r.typeArgs[name]; ok {
+			tv, err := types.Eval(r.fset, r.pkg, r.pos, expr)
+			if err != nil {
+				return nil, NewError("error.type_arg_invalid", name, expr, err).at(r.fset.Position(tparam.Obj().Pos()))
+			}
+			if !tv.IsType() {
+				return nil, NewError("error.type_arg_not_type", name, expr).at(r.fset.Position(tparam.Obj().Pos()))
+			}
+			results = append(results, tv.Type)
+			continue
+		}
+		typeArg, ok := typeArgFromConstraint(tparam)
+		if !ok {
+			return nil, NewError("error.type_arg_unresolved", name, types.TypeString(tparam.Constraint(), packageQualifier(r.pkg))).at(r.fset.Position(tparam.Obj().Pos()))
+		}
+		if !r.reported[name] {
+			r.reported[name] = true
+			r.diagnostics = append(r.diagnostics, newDiagnostic(
+				r.fset.Position(tparam.Obj().Pos()), "diagnostic.type_arg_selected",
+				name, types.TypeString(typeArg, packageQualifier(r.pkg)),
+			))
+		}
+		results = append(results, typeArg)
+	}
+	return results, nil
+}
+
+// typeArgFromConstraint 制約を満たす型を選択する
+// 選択した型が制約を満たさない場合(例: メソッドを持つcomparable)はfalse
+func typeArgFromConstraint(src *types.TypeParam) (types.Type, bool) {
+	typeArg := candidateTypeArg(src)
+	iface, ok := src.Constraint().Underlying().(*types.Interface)
+	if !ok {
+		return typeArg, true
+	}
+	return typeArg, types.Implements(typeArg, iface)
+}
+
+// candidateTypeArg 制約から型を選択する
+// 型の集合を持つ制約はその先頭の型を、comparableはintを、それ以外は制約のインタフェース自体を選択する
+func candidateTypeArg(src *types.TypeParam) types.Type {
+	iface, ok := src.Constraint().Underlying().(*types.Interface)
+	if !ok {
+		return src.Constraint()
+	}
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		switch embedded := iface.EmbeddedType(i).(type) {
+		case *types.Union:
+			if embedded.Len() != 0 {
+				return embedded.Term(0).Type()
+			}
+		case *types.Interface:
+			continue
+		default:
+			if _, ok := embedded.Underlying().(*types.Interface); !ok {
+				return embedded
+			}
+		}
+	}
+	if iface.IsComparable() {
+		return types.Typ[types.Int]
+	}
+	return src.Constraint()
+}
+
+// instantiateStruct 型パラメータを持つ構造体を、具体的な型で実体化する
+func (r *typeArgResolver) instantiateStruct(src *types.Named) (*types.Named, error) {
+	if src.TypeParams().Len() == 0 {
+		return src, nil
+	}
+	typeArgs, err := r.resolve(src.TypeParams())
+	if err != nil {
+		return nil, err
+	}
+	inst, err := types.Instantiate(nil, src.Origin(), typeArgs, true)
+	if err != nil {
+		return nil, err
+	}
+	named, ok := inst.(*types.Named)
+	if !ok {
+		return nil, NewError("error.instance_not_struct").at(r.fset.Position(src.Obj().Pos()))
+	}
+	return named, nil
+}
+
+// extractInstantiations 型パラメータを持つテスト対象のメソッドや関数を、具体的な型で実体化した情報を抽出する
+// recvType: 実体化したテスト対象の構造体(型パラメータを持たない場合も含む)
+func extractInstantiations(src *ast.File, info *types.Info, recvType *types.Named, targetStructName string, r *typeArgResolver) (map[string]*Instantiation, error) {
+	instantiations := map[string]*Instantiation{}
+	for _, decl := range src.Decls {
+		fDecl, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		obj, ok := info.Defs[fDecl.Name].(*types.Func)
+		if !ok {
+			continue
+		}
+		sig := obj.Type().(*types.Signature)
+		switch {
+		case fDecl.Recv != nil && sig.RecvTypeParams().Len() != 0:
+			recvTypeName, err := extractRecvTypeName(fDecl.Recv.List[0].Type)
+			if err != nil || recvTypeName != targetStructName || recvType == nil {
+				continue
+			}
+			_, isStar := fDecl.Recv.List[0].Type.(*ast.StarExpr)
+			methodObj, _, _ := types.LookupFieldOrMethod(recvType, true, r.pkg, fDecl.Name.Name)
+			method, ok := methodObj.(*types.Func)
+			if !ok {
+				continue
+			}
+			inst := createInstantiation(method.Type().(*types.Signature), r.pkg)
+			inst.RecvValue = types.TypeString(recvType, packageQualifier(r.pkg))
+			inst.RecvIsStar = isStar
+			inst.TestName = "Test" + recvTypeName + "_" + fDecl.Name.Name
+			if !ast.IsExported(recvTypeName) {
+				inst.TestName = "Test_" + recvTypeName + "_" + fDecl.Name.Name
+			}
+			recvTypeArgs := make([]types.Type, 0, recvType.TypeArgs().Len())
+			for i := 0; i < recvType.TypeArgs().Len(); i++ {
+				recvTypeArgs = append(recvTypeArgs, recvType.TypeArgs().At(i))
+			}
+			inst.TypeArgs = createTypeArgsMap(sig.RecvTypeParams(), recvTypeArgs, r.pkg)
+			instantiations[fDecl.Name.Name] = inst
+		case fDecl.Recv == nil && sig.TypeParams().Len() != 0:
+			typeArgs, err := r.resolve(sig.TypeParams())
+			// 型引数を選択できない関数はテストを生成せず、他の関数の解析を続ける
+			var tgenErr *Error
+			if errors.As(err, &tgenErr) && tgenErr.Key == "error.type_arg_unresolved" {
+				r.diagnostics = append(r.diagnostics, newDiagnostic(tgenErr.Position, "diagnostic.type_arg_unresolved", append([]interface{}{fDecl.Name.Name}, tgenErr.Args...)...))
+				instantiations[fDecl.Name.Name] = &Instantiation{Unresolved: true}
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			instSig, err := types.Instantiate(nil, sig, typeArgs, true)
+			if err != nil {
+				return nil, err
+			}
+			inst := createInstantiation(instSig.(*types.Signature), r.pkg)
+			inst.TypeArgs = createTypeArgsMap(sig.TypeParams(), typeArgs, r.pkg)
+			typeArgStrings := make([]string, 0, len(typeArgs))
+			for _, typeArg := range typeArgs {
+				typeArgStrings = append(typeArgStrings, types.TypeString(typeArg, packageQualifier(r.pkg)))
+			}
+			inst.CallTypeArgs = "[" + strings.Join(typeArgStrings, ", ") + "]"
+			instantiations[fDecl.Name.Name] = inst
+		}
+	}
+	return instantiations, nil
+}
+
+// createInstantiation 実体化したシグネチャから、引数と戻り値の型を抽出する
+// 引数と戻り値の名前は、gotestsのテンプレートで利用される名前に合わせる
+func createInstantiation(sig *types.Signature, pkg *types.Package) *Instantiation {
+	qualifier := packageQualifier(pkg)
+	inst := &Instantiation{
+		Params: make(map[string]string, sig.Params().Len()),
+		sig:    sig,
+	}
+	for i := 0; i < sig.Params().Len(); i++ {
+		param := sig.Params().At(i)
+		name := param.Name()
+		if name == "" || name == "_" {
+			name = fmt.Sprintf("in%d", i)
+		}
+		inst.Params[name] = types.TypeString(param.Type(), qualifier)
+	}
+	for i := 0; i < sig.Results().Len(); i++ {
+		resultType := types.TypeString(sig.Results().At(i).Type(), qualifier)
+		// gotestsではerrorの戻り値はResultsに含まれない
+		if resultType == "error" {
+			continue
+		}
+		inst.Results = append(inst.Results, resultType)
+	}
+	return inst
+}
+
+// createTypeArgsMap 型パラメータ名と、選択した型の組み合わせを作成する
+func createTypeArgsMap(tparams *types.TypeParamList, typeArgs []types.Type, pkg *types.Package) map[string]string {
+	typeArgsMap := make(map[string]string, tparams.Len())
+	for i := 0; i < tparams.Len() && i < len(typeArgs); i++ {
+		typeArgsMap[tparams.At(i).Obj().Name()] = types.TypeString(typeArgs[i], packageQualifier(pkg))
+	}
+	return typeArgsMap
+}
+
+// instantiateArgFieldMap mock化する引数の情報を、実体化した引数の型で作り直す
+func instantiateArgFieldMap(argFieldMap map[string]map[string]*FieldInfo, instantiations map[string]*Instantiation, pkg *types.Package) {
+	for funcName, inst := range instantiations {
+		fieldMap, ok := argFieldMap[funcName]
+		if !ok || inst.Unresolved {
+			continue
+		}
+		for i := 0; i < inst.sig.Params().Len(); i++ {
+			param := inst.sig.Params().At(i)
+			if _, ok := fieldMap[param.Name()]; ok {
+				fieldMap[param.Name()] = createFieldInfo(param.Type(), pkg)
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package internal
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestExtractInstantiations(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		typeArgs map[string]string
+		// テスト対象の関数名
+		funcName string
+		want     *Instantiation
+		// 制約から型を選択したことを伝える内容の数
+		wantSelected int
+	}{
+		{
+			name:         "union constraint selects the first term",
+			src:          "func Sum[T int | float64](xs []T) T { return xs[0] }",
+			funcName:     "Sum",
+			want:         &Instantiation{TypeArgs: map[string]string{"T": "int"}, Params: map[string]string{"xs": "[]int"}, Results: []string{"int"}, CallTypeArgs: "[int]"},
+			wantSelected: 1,
+		},
+		{
+			name:         "approximation constraint selects the underlying type",
+			src:          "type Name interface{ ~string }\n\nfunc Upper[T Name](s T) T { return s }",
+			funcName:     "Upper",
+			want:         &Instantiation{TypeArgs: map[string]string{"T": "string"}, Params: map[string]string{"s": "string"}, Results: []string{"string"}, CallTypeArgs: "[string]"},
+			wantSelected: 1,
+		},
+		{
+			name:         "comparable selects int and any selects any",
+			src:          "func Keys[K comparable, V any](m map[K]V) []K { return nil }",
+			funcName:     "Keys",
+			want:         &Instantiation{TypeArgs: map[string]string{"K": "int", "V": "any"}, Params: map[string]string{"m": "map[int]any"}, Results: []string{"[]int"}, CallTypeArgs: "[int, any]"},
+			wantSelected: 2,
+		},
+		{
+			name:     "type args specified with --type_args",
+			src:      "func Sum[T int | int64](xs []T) T { return xs[0] }",
+			typeArgs: map[string]string{"T": "int64"},
+			funcName: "Sum",
+			want:     &Instantiation{TypeArgs: map[string]string{"T": "int64"}, Params: map[string]string{"xs": "[]int64"}, Results: []string{"int64"}, CallTypeArgs: "[int64]"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzeSource(t, "package sample\n\n"+tt.src+"\n", tt.typeArgs)
+			if err != nil {
+				t.Fatalf("GetAnalysisResult() error = %v", err)
+			}
+			got := result.InstantiationMap[tt.funcName]
+			if got == nil {
+				t.Fatalf("InstantiationMap[%q] = nil", tt.funcName)
+			}
+			got.sig = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InstantiationMap[%q] = %+v, want %+v", tt.funcName, got, tt.want)
+			}
+			selected := 0
+			for _, d := range result.Diagnostics {
+				if d.Code == "diagnostic.type_arg_selected" {
+					selected++
+				}
+			}
+			if selected != tt.wantSelected {
+				t.Errorf("diagnostic.type_arg_selected reported %d times, want %d", selected, tt.wantSelected)
+			}
+		})
+	}
+}
+
+func TestExtractInstantiations_invalidTypeArgs(t *testing.T) {
+	src := "package sample\n\nfunc Sum[T int | int64](xs []T) T { return xs[0] }\n"
+	// 型として解釈できない式と、型ではない識別子
+	for typ, wantKey := range map[string]string{"undefined": "error.type_arg_invalid", "len": "error.type_arg_not_type"} {
+		_, err := analyzeSource(t, src, map[string]string{"T": typ})
+		var tgenErr *Error
+		if !errors.As(err, &tgenErr) || tgenErr.Key != wantKey {
+			t.Errorf("GetAnalysisResult() with T=%s error = %v, want %s", typ, err, wantKey)
+		}
+	}
+}
+
+// TestExtractInstantiations_unresolved 制約を満たす型を選択できない関数は、テストを生成せずに利用者に伝えることを確認する
+func TestExtractInstantiations_unresolved(t *testing.T) {
+	src := "package sample\n\ntype Key interface {\n\tcomparable\n\tString() string\n}\n\nfunc Lookup[K Key](k K) string { return k.String() }\n"
+	result, err := analyzeSource(t, src, nil)
+	if err != nil {
+		t.Fatalf("GetAnalysisResult() error = %v", err)
+	}
+	if got := result.InstantiationMap["Lookup"]; got == nil || !got.Unresolved {
+		t.Errorf("InstantiationMap[Lookup] = %+v, want Unresolved", got)
+	}
+	reported := false
+	for _, d := range result.Diagnostics {
+		reported = reported || d.Code == "diagnostic.type_arg_unresolved"
+	}
+	if !reported {
+		t.Errorf("Diagnostics = %v, want diagnostic.type_arg_unresolved", result.Diagnostics)
+	}
+}
+
+// TestExtractInstantiations_mockArgs 型パラメータを持つ構造体のmockで期待する引数を、型のゼロ値もしくはgomock.Any()にすることを確認する
+func TestExtractInstantiations_mockArgs(t *testing.T) {
+	src := `package sample
+
+import "context"
+
+type Repository[T any] interface {
+	Get(ctx context.Context, id int) (T, error)
+	Put(ctx context.Context, id int, v T) error
+}
+
+type Service[T any] struct {
+	Repo Repository[T]
+}
+
+func (s *Service[T]) Save(ctx context.Context, id int, v T) error {
+	_, err := s.Repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.Repo.Put(ctx, id, v)
+}
+`
+	result, err := analyzeSource(t, src, nil)
+	if err != nil {
+		t.Fatalf("GetAnalysisResult() error = %v", err)
+	}
+	testCases := CreateTemplateParams(result, "en").TargetMethodTesCasesMap["Save"]
+	if len(testCases) == 0 {
+		t.Fatal("no test cases of Save")
+	}
+	got := map[string]string{}
+	for _, mockMethod := range testCases[len(testCases)-1].DepMethodsInField["Repo"] {
+		got[mockMethod.Name] = mockMethod.Arg
+	}
+	// 型パラメータの引数は、実体化した型が分からないためgomock.Any()にする
+	want := map[string]string{"Get": "gomock.Any(),0", "Put": "gomock.Any(),0,gomock.Any()"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("args of the mocks = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,195 @@
+package internal
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// GRPCInfo gRPCのサービスのメソッド(例: func (s *Server) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error))の情報
+type GRPCInfo struct {
+	// 実装しているサービスのインタフェース(例: pb.UserServiceServer)
+	Service string
+	// リクエストの引数名
+	Request string
+	// リクエストのメッセージの各フィールドにゼロ値を設定したリテラル(例: &pb.GetUserRequest{Id: ""})
+	RequestValue string
+}
+
+// gRPCのステータスコード
+const (
+	// codeOK エラーを返さない場合のステータスコード
+	codeOK = "codes.OK"
+	// codeUnknown statusパッケージで作成していないエラーを返す場合の、status.Codeが返すステータスコード
+	codeUnknown = "codes.Unknown"
+)
+
+const (
+	grpcStatusPath = "google.golang.org/grpc/status"
+	grpcCodesPath  = "google.golang.org/grpc/codes"
+)
+
+// grpcInfo テスト対象のメソッドが、生成されたgRPCのサービスのインタフェース(XxxServer)のunaryなメソッドを実装している場合に、その情報を返す
+// 実装していない場合はnil
+func (r *depResolver) grpcInfo(src *ast.FuncDecl) *GRPCInfo {
+	if r.info == nil || src.Recv == nil {
+		return nil
+	}
+	obj, ok := r.info.Defs[src.Name].(*types.Func)
+	if !ok {
+		return nil
+	}
+	sig := obj.Type().(*types.Signature)
+	params, results := sig.Params(), sig.Results()
+	if params.Len() != 2 || results.Len() != 2 || !isContextType(params.At(0).Type()) || !isErrorType(results.At(1).Type()) {
+		return nil
+	}
+	reqPtr, ok := params.At(1).Type().(*types.Pointer)
+	if !ok {
+		return nil
+	}
+	req, ok := reqPtr.Elem().(*types.Named)
+	if !ok {
+		return nil
+	}
+	if _, ok := req.Underlying().(*types.Struct); !ok {
+		return nil
+	}
+	service := r.grpcService(sig.Recv().Type(), obj.Name())
+	if service == "" {
+		return nil
+	}
+	reqName := "in1"
+	if name := params.At(1).Name(); name != "" && name != "_" {
+		reqName = name
+	}
+	return &GRPCInfo{
+		Service:      service,
+		Request:      reqName,
+		RequestValue: r.messageLiteral(req),
+	}
+}
+
+// grpcService レシーバーの型が実装している、指定したメソッドを持つサービスのインタフェース(名前がServerで終わるインタフェース)を探す
+// テスト対象のパッケージと、そのパッケージがimportしているパッケージから探す
+func (r *depResolver) grpcService(recv types.Type, method string) string {
+	if _, ok := recv.(*types.Pointer); !ok {
+		recv = types.NewPointer(recv)
+	}
+	pkgs := append([]*types.Package{r.pkg}, r.pkg.Imports()...)
+	for _, pkg := range pkgs {
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			if !strings.HasSuffix(name, "Server") {
+				continue
+			}
+			typeName, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok {
+				continue
+			}
+			iface, ok := typeName.Type().Underlying().(*types.Interface)
+			if !ok || !hasMethod(iface, method) || !types.Implements(recv, iface) {
+				continue
+			}
+			return types.TypeString(typeName.Type(), packageQualifier(r.pkg))
+		}
+	}
+	return ""
+}
+
+// hasMethod インタフェースが指定した名前のメソッドを持つか否か
+func hasMethod(src *types.Interface, name string) bool {
+	for i := 0; i < src.NumMethods(); i++ {
+		if src.Method(i).Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// messageLiteral メッセージの公開されている各フィールドにゼロ値を設定した、ポインタのリテラル
+func (r *depResolver) messageLiteral(src *types.Named) string {
+	var b strings.Builder
+	b.WriteString("&" + types.TypeString(src, packageQualifier(r.pkg)) + "{\n")
+	st := src.Underlying().(*types.Struct)
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if !field.Exported() || field.Embedded() {
+			continue
+		}
+		b.WriteString(field.Name() + ": " + r.zeroValue(field.Type()) + ",\n")
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// branchCode if文の分岐で返すエラーから、status.Codeが返すステータスコードを決める(決められない場合は空文字)
+// status.Error(codes.X, ...)・status.Errorf(codes.X, ...)・status.New(codes.X, ...).Err(): codes.X
+// errors.New・fmt.Errorf・mock化するメソッドが返したエラー: codes.Unknown
+func (r *depResolver) branchCode(src *ast.BlockStmt, wantErr *WantErr) string {
+	if r.grpc == nil || len(src.List) == 0 {
+		return ""
+	}
+	returnStmt, ok := src.List[len(src.List)-1].(*ast.ReturnStmt)
+	if !ok || len(returnStmt.Results) == 0 {
+		return ""
+	}
+	if code := r.statusCode(returnStmt.Results[len(returnStmt.Results)-1]); code != "" {
+		return code
+	}
+	switch wantErr.Kind {
+	case WantErrNone:
+		return codeOK
+	case WantErrMessage, WantErrMock:
+		return codeUnknown
+	}
+	return ""
+}
+
+// statusCode statusパッケージでエラーを作成する式の場合に、そのステータスコードの式を返す
+func (r *depResolver) statusCode(src ast.Expr) string {
+	call, ok := astutil.Unparen(src).(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	fun, ok := astutil.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	callee, ok := r.info.Uses[fun.Sel].(*types.Func)
+	if !ok || callee.Pkg() == nil {
+		return ""
+	}
+	// status.New(codes.X, ...).Err()(*status.Statusはgrpcの内部パッケージの型の別名)
+	if callee.Name() == "Err" && strings.HasPrefix(callee.Pkg().Path(), "google.golang.org/grpc/") {
+		return r.statusCode(fun.X)
+	}
+	if callee.Pkg().Path() != grpcStatusPath {
+		return ""
+	}
+	switch callee.Name() {
+	case "Error", "Errorf", "New", "Newf":
+		if len(call.Args) > 0 {
+			return r.codeExpr(call.Args[0])
+		}
+	}
+	return ""
+}
+
+// codeExpr ステータスコードの式を、テストコードで利用できる式にする
+// codesパッケージの定数(例: codes.NotFound)はそのまま、その他の定数は値をcodes.Codeに変換する(定数ではない場合は空文字)
+func (r *depResolver) codeExpr(src ast.Expr) string {
+	if sel, ok := astutil.Unparen(src).(*ast.SelectorExpr); ok {
+		if c, ok := r.info.Uses[sel.Sel].(*types.Const); ok && c.Pkg() != nil && c.Pkg().Path() == grpcCodesPath {
+			return "codes." + c.Name()
+		}
+	}
+	tv, ok := r.info.Types[src]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.Int {
+		return ""
+	}
+	return "codes.Code(" + tv.Value.ExactString() + ")"
+}
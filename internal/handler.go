@@ -0,0 +1,201 @@
+package internal
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// HTTPハンドラーの種類
+const (
+	// HandlerNetHTTP func(w http.ResponseWriter, r *http.Request)
+	HandlerNetHTTP = "net/http"
+	// HandlerEcho func(c echo.Context) error
+	HandlerEcho = "echo"
+	// HandlerGin func(c *gin.Context)
+	HandlerGin = "gin"
+)
+
+// statusOK ステータスコードを設定しない場合に、httptest.ResponseRecorderが返すステータスコード
+const statusOK = "http.StatusOK"
+
+// statusInternalServerError echoのハンドラーがステータスコードを持たないエラーを返した場合の、ステータスコード
+const statusInternalServerError = "http.StatusInternalServerError"
+
+// handlerKind テスト対象の関数がHTTPハンドラーの場合に、その種類を返す(HTTPハンドラーではない場合は空文字)
+func (r *depResolver) handlerKind(src *ast.FuncDecl) string {
+	if r.info == nil {
+		return ""
+	}
+	obj, ok := r.info.Defs[src.Name].(*types.Func)
+	if !ok {
+		return ""
+	}
+	sig := obj.Type().(*types.Signature)
+	params, results := sig.Params(), sig.Results()
+	switch {
+	case params.Len() == 2 && results.Len() == 0 &&
+		isNamedType(params.At(0).Type(), "net/http", "ResponseWriter") && isNamedType(params.At(1).Type(), "net/http", "*Request"):
+		return HandlerNetHTTP
+	case params.Len() == 1 && results.Len() == 1 &&
+		isEchoContext(params.At(0).Type()) && isErrorType(results.At(0).Type()):
+		return HandlerEcho
+	case params.Len() == 1 && results.Len() == 0 &&
+		isNamedType(params.At(0).Type(), "github.com/gin-gonic/gin", "*Context"):
+		return HandlerGin
+	}
+	return ""
+}
+
+// isNamedType 指定したパッケージの型か否か(名前の先頭の*はポインタを表す)
+func isNamedType(src types.Type, pkgPath, name string) bool {
+	if len(name) > 0 && name[0] == '*' {
+		ptr, ok := src.(*types.Pointer)
+		if !ok {
+			return false
+		}
+		src, name = ptr.Elem(), name[1:]
+	}
+	named, ok := src.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+	return named.Obj().Pkg().Path() == pkgPath && named.Obj().Name() == name
+}
+
+// isEchoContext echo.Context(v4とそれ以前)か否か
+func isEchoContext(src types.Type) bool {
+	return isNamedType(src, "github.com/labstack/echo/v4", "Context") || isNamedType(src, "github.com/labstack/echo", "Context")
+}
+
+// branchStatus if文の分岐で返すステータスコードを、分岐の中で最後にステータスコードを設定している呼び出しから決める
+// 決められない場合は空文字
+func (r *depResolver) branchStatus(src *ast.BlockStmt) string {
+	if r.handler == "" || r.info == nil {
+		return ""
+	}
+	status := ""
+	ast.Inspect(src, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.CallExpr:
+			if s := r.callStatus(n); s != "" {
+				status = s
+			}
+		}
+		return true
+	})
+	if status != "" || r.handler != HandlerEcho || len(src.List) == 0 {
+		return status
+	}
+	// echoはステータスコードを持たないエラーを500として扱う
+	returnStmt, ok := src.List[len(src.List)-1].(*ast.ReturnStmt)
+	if !ok || len(returnStmt.Results) != 1 || r.isNil(astutil.Unparen(returnStmt.Results[0])) {
+		return ""
+	}
+	return statusInternalServerError
+}
+
+// successStatus 正常系で返すステータスコードを、関数の本体(if文などのブロックの中は除く)で最後にステータスコードを設定している呼び出しから決める
+// ステータスコードを設定していない場合は200にする
+func (r *depResolver) successStatus(src *ast.FuncDecl) string {
+	if r.handler == "" || src.Body == nil {
+		return ""
+	}
+	status := statusOK
+	for _, stmt := range src.Body.List {
+		var call *ast.CallExpr
+		switch s := stmt.(type) {
+		case *ast.ExprStmt:
+			call, _ = astutil.Unparen(s.X).(*ast.CallExpr)
+		case *ast.ReturnStmt:
+			if len(s.Results) == 1 {
+				call, _ = astutil.Unparen(s.Results[0]).(*ast.CallExpr)
+			}
+		}
+		if call == nil {
+			continue
+		}
+		if s := r.callStatus(call); s != "" {
+			status = s
+		}
+	}
+	return status
+}
+
+// callStatus ステータスコードを設定する呼び出しの場合に、そのステータスコードの式を返す
+// net/http: w.WriteHeader(code), http.Error(w, msg, code), http.NotFound(w, r), http.Redirect(w, r, url, code)
+// echo: c.JSON(code, v)などのレスポンスを返すメソッド, echo.NewHTTPError(code)
+// gin: c.JSON(code, v)などのレスポンスを返すメソッド, c.Status(code), c.AbortWithStatus(code)
+func (r *depResolver) callStatus(src *ast.CallExpr) string {
+	var fn *types.Func
+	switch f := astutil.Unparen(src.Fun).(type) {
+	case *ast.SelectorExpr:
+		fn, _ = r.info.Uses[f.Sel].(*types.Func)
+	case *ast.Ident:
+		fn, _ = r.info.Uses[f].(*types.Func)
+	}
+	if fn == nil || fn.Pkg() == nil {
+		return ""
+	}
+	sig := fn.Type().(*types.Signature)
+	pkgPath := fn.Pkg().Path()
+	switch {
+	case pkgPath == "net/http" && sig.Recv() != nil:
+		if fn.Name() == "WriteHeader" && len(src.Args) == 1 {
+			return r.statusExpr(src.Args[0])
+		}
+	case pkgPath == "net/http":
+		switch fn.Name() {
+		case "Error":
+			if len(src.Args) == 3 {
+				return r.statusExpr(src.Args[2])
+			}
+		case "NotFound":
+			return "http.StatusNotFound"
+		case "Redirect":
+			if len(src.Args) == 4 {
+				return r.statusExpr(src.Args[3])
+			}
+		}
+	case pkgPath == "github.com/labstack/echo/v4" || pkgPath == "github.com/labstack/echo":
+		if sig.Recv() == nil && fn.Name() != "NewHTTPError" {
+			return ""
+		}
+		switch fn.Name() {
+		case "NewHTTPError", "JSON", "JSONPretty", "JSONBlob", "JSONP", "JSONPBlob", "XML", "XMLPretty", "XMLBlob",
+			"String", "HTML", "HTMLBlob", "Blob", "Stream", "NoContent", "Redirect", "Render":
+			if len(src.Args) > 0 {
+				return r.statusExpr(src.Args[0])
+			}
+		}
+	case pkgPath == "github.com/gin-gonic/gin" && sig.Recv() != nil:
+		switch fn.Name() {
+		case "JSON", "IndentedJSON", "SecureJSON", "JSONP", "AsciiJSON", "PureJSON", "XML", "YAML", "TOML", "ProtoBuf",
+			"String", "HTML", "Data", "DataFromReader", "Redirect", "Status",
+			"AbortWithStatus", "AbortWithStatusJSON", "AbortWithError":
+			if len(src.Args) > 0 {
+				return r.statusExpr(src.Args[0])
+			}
+		}
+	}
+	return ""
+}
+
+// statusExpr ステータスコードの式を、テストコードで利用できる式にする
+// net/httpの定数(例: http.StatusBadRequest)はそのまま、その他の定数は値にする(定数ではない場合は空文字)
+func (r *depResolver) statusExpr(src ast.Expr) string {
+	if sel, ok := astutil.Unparen(src).(*ast.SelectorExpr); ok {
+		if c, ok := r.info.Uses[sel.Sel].(*types.Const); ok && c.Pkg() != nil && c.Pkg().Path() == "net/http" {
+			return "http." + c.Name()
+		}
+	}
+	tv, ok := r.info.Types[src]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.Int {
+		return ""
+	}
+	return tv.Value.ExactString()
+}
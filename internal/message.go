@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"fmt"
+	"go/token"
+)
+
+// 対応している言語
+const (
+	LangJa = "ja"
+	LangEn = "en"
+)
+
+// messages 言語ごとの、メッセージのキーと書式の組み合わせ
+// テンプレートに渡る数値はjsonを経由してfloat64になるため、テンプレートで利用する書式には%vを利用する
+// テンプレートにはTemplateParams.Messagesとして渡されるため、独自のテンプレートでもキーを指定して利用できる
+var messages = map[string]map[string]string{
+	LangJa: {
+		"testcase.success":                "正常",
+		"testcase.cancel":                 "異常: コンテキストのキャンセル",
+		"testcase.if":                     "異常: %v行目のif文",
+		"testcase.branch":                 "異常: %s",
+		"testcase.cond.true":              "%sがtrue",
+		"testcase.cond.false":             "%sがfalse",
+		"testcase.cond.returns_error":     "%sがerrorを返す",
+		"testcase.added_todo":             "TODO tgenが追加した分岐のテストケースです。期待値とmockの引数・戻り値を設定してください",
+		"error.pkgs_not_one":              "pkgsの中身は一つを想定しています",
+		"error.syntax_not_found":          "対象ファイルの構文木を読み取れていません",
+		"error.struct_not_unique":         "テスト対象のメソッドを持つ構造体が一意に定まりません",
+		"error.scope_not_found":           "構造体の型情報を読み取れていません",
+		"error.struct_not_found":          "対象の構造体の情報を読み取れていません",
+		"error.not_struct":                "読み取った構造体の型は構造体ではありません",
+		"error.instance_not_struct":       "実体化した型が構造体ではありません",
+		"error.unexpected_format":         "想定していないデータ形式です",
+		"error.type_arg_invalid":          "型パラメータ%sに指定した型(%s)を解釈できません: %v",
+		"error.type_arg_not_type":         "型パラメータ%sに指定した%sは型ではありません",
+		"error.type_arg_unresolved":       "型パラメータ%sの制約(%s)を満たす型を選択できません。--type_argsで指定してください",
+		"error.result_nil":                "解析結果がnilです",
+		"error.templates_not_found":       "テンプレート(*.tmpl)が見つかりません",
+		"error.test_not_generated":        "%sのテストを生成できませんでした",
+		"diagnostic.concrete_field":       "%s.%s(%s)は具象型のためmock化できません。利用しているメソッドをインタフェースとして抽出し、フィールドの型をそのインタフェースにすることを検討してください",
+		"diagnostic.type_arg_selected":    "型パラメータ%sには制約から%sを選択しました。変更する場合は--type_argsで指定してください",
+		"diagnostic.type_arg_unresolved":  "型パラメータ%[2]sの制約(%[3]s)を満たす型を選択できないため、%[1]sのテストを生成していません。--type_argsで指定してください",
+		"diagnostic.skipped_if_err":       "%sのif文(%s)はエラーの確認のため、テストケースにしていません",
+		"diagnostic.skipped_if_no_return": "%sのif文(%s)はreturn文で終わらないため、テストケースにしていません",
+		"diagnostic.skipped_switch":       "%sのswitch文・select文は分岐ごとのテストケースにしていません(分岐内のmockは全て一つのテストケースに含まれます)",
+		"diagnostic.multi_call_return":    "%sのreturn文に複数のmock化するメソッドが含まれるため、mockの戻り値の数が正しくない可能性があります",
+		"diagnostic.no_test_cases":        "%sは依存しているメソッドを呼び出していないため、テストケースを生成していません",
+		"diagnostic.nested_mock":          "%sで利用しているmock(%s)はネストしたフィールドのため、TODOコメントとして出力します",
+		"diagnostic.branch_not_forced":    "%sのif文(%s)の分岐に入るためにmockが返す値を決められないため、テストケースにTODOコメントを出力します",
+		"diagnostic.nondeterministic":     "%sは実行ごとに結果が変わる%sを呼び出しているため、テストの結果が安定しない可能性があります。差し替えられるように注入することを検討してください(--suggest_clockで注入するフィールドの候補を表示します)",
+		"vet.missing_test":                "%sのテスト(%s)がありません",
+		"vet.missing_case":                "%sのテスト(%s)に「%s」のテストケースがありません",
+	},
+	LangEn: {
+		"testcase.success":                "success",
+		"testcase.cancel":                 "error: context canceled",
+		"testcase.if":                     "error: if statement at line %v",
+		"testcase.branch":                 "%s",
+		"testcase.cond.true":              "%s is true",
+		"testcase.cond.false":             "%s is false",
+		"testcase.cond.returns_error":     "%s returns error",
+		"testcase.added_todo":             "TODO test case for a new branch added by tgen. Set the expected values and the mock arguments and return values",
+		"error.pkgs_not_one":              "expected exactly one package",
+		"error.syntax_not_found":          "could not read the syntax tree of the target file",
+		"error.struct_not_unique":         "the struct that has the target methods is not unique",
+		"error.scope_not_found":           "could not read the type information of the struct",
+		"error.struct_not_found":          "could not read the target struct",
+		"error.not_struct":                "the type read as the target struct is not a struct",
+		"error.instance_not_struct":       "the instantiated type is not a struct",
+		"error.unexpected_format":         "unexpected data format",
+		"error.type_arg_invalid":          "could not interpret the type (%[2]s) given for type parameter %[1]s: %[3]v",
+		"error.type_arg_not_type":         "%[2]s given for type parameter %[1]s is not a type",
+		"error.type_arg_unresolved":       "could not select a type that satisfies the constraint (%[2]s) of type parameter %[1]s. Specify it with --type_args",
+		"error.result_nil":                "the analysis result is nil",
+		"error.templates_not_found":       "no templates (*.tmpl) found",
+		"error.test_not_generated":        "could not generate a test for %s",
+		"diagnostic.concrete_field":       "%s.%s (%s) is a concrete type and cannot be mocked. Consider extracting the methods in use into an interface and using it as the field type",
+		"diagnostic.type_arg_selected":    "selected %[2]s for type parameter %[1]s from its constraint. Use --type_args to change it",
+		"diagnostic.type_arg_unresolved":  "no test is generated for %[1]s since no type satisfies the constraint (%[3]s) of type parameter %[2]s. Specify it with --type_args",
+		"diagnostic.skipped_if_err":       "if statement (%[2]s) in %[1]s checks an error and is not turned into a test case",
+		"diagnostic.skipped_if_no_return": "if statement (%[2]s) in %[1]s does not end with a return statement and is not turned into a test case",
+		"diagnostic.skipped_switch":       "switch/select statement in %s is not split into test cases (all mocks in it are put into one test case)",
+		"diagnostic.multi_call_return":    "return statement in %s contains multiple mocked calls, so the number of mock return values may be wrong",
+		"diagnostic.no_test_cases":        "%s calls no dependent methods, so no test cases are generated",
+		"diagnostic.nested_mock":          "mock of %[2]s used in %[1]s is a nested field and is output as a TODO comment",
+		"diagnostic.branch_not_forced":    "the values the mocks return to enter the if statement (%[2]s) in %[1]s cannot be determined, so a TODO comment is output in its test case",
+		"diagnostic.nondeterministic":     "%s calls %s, whose result changes on each run, so its tests may be flaky. Consider injecting it so that it can be replaced (--suggest_clock shows which field to inject)",
+		"vet.missing_test":                "%s has no test (%s)",
+		"vet.missing_case":                "test of %s (%s) has no case %q",
+	},
+}
+
+// NormalizeLang 言語の指定を、対応している言語に揃える
+// 対応していない言語の場合は日本語にする
+func NormalizeLang(lang string) string {
+	if _, ok := messages[lang]; ok {
+		return lang
+	}
+	return LangJa
+}
+
+// Messages 言語ごとの、メッセージのキーと書式の組み合わせを返す
+func Messages(lang string) map[string]string {
+	return messages[NormalizeLang(lang)]
+}
+
+// Localize キーに対応するメッセージを、指定された言語で作成する
+// キーが存在しない場合は、キーをそのまま返す
+func Localize(lang, key string, args ...interface{}) string {
+	format, ok := Messages(lang)[key]
+	if !ok {
+		format, ok = messages[LangJa][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// newDiagnostic 言語を切り替えて表示できる、利用者に伝えるべき内容を作成する
+// Messageは、テンプレートのパラメータを作成する際に指定された言語で設定される
+func newDiagnostic(position token.Position, key string, args ...interface{}) *Diagnostic {
+	return &Diagnostic{
+		Position: position,
+		Code:     key,
+		Severity: SeverityWarning,
+		args:     args,
+	}
+}
+
+// newSkipDiagnostic テストケースの生成の対象外とした構文を、利用者に伝える内容として作成する
+func newSkipDiagnostic(position token.Position, key string, args ...interface{}) *Diagnostic {
+	d := newDiagnostic(position, key, args...)
+	d.Severity = SeverityInfo
+	return d
+}
+
+// localize 指定された言語でMessageを設定する
+func (d *Diagnostic) localize(lang string) {
+	d.Message = Localize(lang, d.Code, d.args...)
+}
@@ -0,0 +1,415 @@
+package internal
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// mockReturns mock化するメソッドの呼び出し式の位置ごとの、戻り値の位置と返す値の式(例: {位置: {1: "errMock"}})
+type mockReturns map[token.Pos]map[int]string
+
+// mergeMockReturns 複数のmockReturnsをまとめる(同じ戻り値を返す場合は、後に指定したものを優先する)
+func mergeMockReturns(src ...mockReturns) mockReturns {
+	var dest mockReturns
+	for _, returns := range src {
+		for pos, values := range returns {
+			if dest == nil {
+				dest = mockReturns{}
+			}
+			if dest[pos] == nil {
+				dest[pos] = map[int]string{}
+			}
+			for i, value := range values {
+				dest[pos][i] = value
+			}
+		}
+	}
+	return dest
+}
+
+// conditionReturns if文の条件式から、分岐に入るため(take)と分岐に入らないため(avoid)に、mock化するメソッドが返す値を決める
+// 対応している条件式は以下(xはmock化するメソッドの戻り値を代入したローカル変数)
+// x != nil・x == nil: errorはerrMock、ポインタはnew(T)、スライス・マップは空の値を返す
+// x == ErrNotFound・errors.Is(x, sql.ErrNoRows)(errorとパッケージ変数のエラーの比較): そのエラーもしくはnilを返す
+// x・!x(bool), s.Repo.IsValid()(boolを返すmock化するメソッドの呼び出し): trueもしくはfalseを返す
+// len(x) > 0・len(x) == 0など: 要素を1つ持つ値もしくはゼロ値を返す
+// x > 0・x == "admin"など(整数・文字列と定数の比較): 条件式を満たす値もしくは満たさない値を返す
+// &&・||で組み合わせた条件式: それぞれの条件式から決める
+func (r *depResolver) conditionReturns(src ast.Expr) (take, avoid mockReturns) {
+	if r.info == nil {
+		return nil, nil
+	}
+	switch expr := astutil.Unparen(src).(type) {
+	case *ast.UnaryExpr:
+		if expr.Op == token.NOT {
+			take, avoid = r.conditionReturns(expr.X)
+			return avoid, take
+		}
+	case *ast.Ident:
+		result, ok := r.mockResults[r.info.Uses[expr]]
+		if ok && isBoolType(r.info.TypeOf(expr)) {
+			return result.returns("true"), result.returns("false")
+		}
+	case *ast.CallExpr:
+		if take, avoid, ok := r.errorsIsReturns(expr); ok {
+			return take, avoid
+		}
+		depMethod, ok := r.resolve(expr)
+		if !ok {
+			return nil, nil
+		}
+		if mockMethod, isMock := depMethod.(*MockMethod); isMock && mockMethod.ReturnLen == 1 && isBoolType(r.info.TypeOf(expr)) {
+			result := &mockResult{call: expr.Pos()}
+			return result.returns("true"), result.returns("false")
+		}
+	case *ast.BinaryExpr:
+		switch expr.Op {
+		case token.LAND:
+			// 両方を満たす場合に分岐に入り、どちらかを満たさない場合は分岐に入らない
+			xTake, xAvoid := r.conditionReturns(expr.X)
+			yTake, yAvoid := r.conditionReturns(expr.Y)
+			if xAvoid == nil {
+				xAvoid = yAvoid
+			}
+			return mergeMockReturns(xTake, yTake), xAvoid
+		case token.LOR:
+			// どちらかを満たす場合に分岐に入り、両方を満たさない場合は分岐に入らない
+			xTake, xAvoid := r.conditionReturns(expr.X)
+			yTake, yAvoid := r.conditionReturns(expr.Y)
+			if xTake == nil {
+				xTake = yTake
+			}
+			return xTake, mergeMockReturns(xAvoid, yAvoid)
+		case token.EQL, token.NEQ:
+			if take, avoid, ok := r.nilCheckReturns(expr); ok {
+				return take, avoid
+			}
+			if take, avoid, ok := r.sentinelCheckReturns(expr.X, expr.Y, expr.Op == token.EQL); ok {
+				return take, avoid
+			}
+		}
+		if take, avoid, ok := r.constCheckReturns(expr); ok {
+			return take, avoid
+		}
+		return r.lenCheckReturns(expr)
+	}
+	return nil, nil
+}
+
+// nilCheckReturns x != nil・x == nilの条件式から、mock化するメソッドが返す値を決める
+func (r *depResolver) nilCheckReturns(src *ast.BinaryExpr) (take, avoid mockReturns, ok bool) {
+	x, y := astutil.Unparen(src.X), astutil.Unparen(src.Y)
+	if r.isNil(x) {
+		x, y = y, x
+	}
+	ident, ok := x.(*ast.Ident)
+	if !ok || !r.isNil(y) {
+		return nil, nil, false
+	}
+	result, ok := r.mockResults[r.info.Uses[ident]]
+	if !ok {
+		return nil, nil, false
+	}
+	nonNil, ok := r.nonNilValue(r.info.TypeOf(ident))
+	if !ok {
+		return nil, nil, false
+	}
+	if src.Op == token.NEQ {
+		return result.returns(nonNil), result.returns("nil"), true
+	}
+	return result.returns("nil"), result.returns(nonNil), true
+}
+
+// errorsIsReturns errors.Is(x, ErrNotFound)の条件式から、mock化するメソッドが返す値を決める
+func (r *depResolver) errorsIsReturns(src *ast.CallExpr) (take, avoid mockReturns, ok bool) {
+	fun, isSelector := astutil.Unparen(src.Fun).(*ast.SelectorExpr)
+	if !isSelector || len(src.Args) != 2 {
+		return nil, nil, false
+	}
+	callee, isFunc := r.info.Uses[fun.Sel].(*types.Func)
+	if !isFunc || callee.Pkg() == nil || callee.Pkg().Path() != "errors" || callee.Name() != "Is" {
+		return nil, nil, false
+	}
+	return r.sentinelCheckReturns(src.Args[0], src.Args[1], true)
+}
+
+// sentinelCheckReturns errorの戻り値とパッケージ変数のエラーの比較(例: x == sql.ErrNoRows)の条件式から、mock化するメソッドが返す値を決める
+// equal: 一致する場合に分岐に入るか(x == ErrNotFound, errors.Is(x, ErrNotFound))
+// 分岐に入るためにはそのエラーを、分岐に入らないためにはnilを返す
+func (r *depResolver) sentinelCheckReturns(x, y ast.Expr, equal bool) (take, avoid mockReturns, ok bool) {
+	if _, isMock := r.mockResults[r.info.Uses[identOf(x)]]; !isMock {
+		x, y = y, x
+	}
+	ident := identOf(x)
+	result, isMock := r.mockResults[r.info.Uses[ident]]
+	if !isMock || !isErrorType(r.info.TypeOf(ident)) {
+		return nil, nil, false
+	}
+	var sentinel types.Object
+	switch expr := astutil.Unparen(y).(type) {
+	case *ast.Ident:
+		sentinel = r.info.Uses[expr]
+	case *ast.SelectorExpr:
+		sentinel = r.info.Uses[expr.Sel]
+	}
+	if !isSentinelErr(sentinel) {
+		return nil, nil, false
+	}
+	value := types.ExprString(astutil.Unparen(y))
+	if equal {
+		return result.returns(value), result.returns("nil"), true
+	}
+	return result.returns("nil"), result.returns(value), true
+}
+
+// lenCheckReturns len(x)と整数の比較(例: len(x) > 0, 0 == len(x))の条件式から、mock化するメソッドが返す値を決める
+// 長さが0の場合と1の場合で条件式の結果が変わる場合のみ、ゼロ値もしくは要素を1つ持つ値を返す
+func (r *depResolver) lenCheckReturns(src *ast.BinaryExpr) (take, avoid mockReturns) {
+	switch src.Op {
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+	default:
+		return nil, nil
+	}
+	lenExpr, n, op := src.X, src.Y, src.Op
+	if _, ok := astutil.Unparen(lenExpr).(*ast.CallExpr); !ok {
+		lenExpr, n = n, lenExpr
+		switch op {
+		case token.LSS:
+			op = token.GTR
+		case token.LEQ:
+			op = token.GEQ
+		case token.GTR:
+			op = token.LSS
+		case token.GEQ:
+			op = token.LEQ
+		}
+	}
+	call, ok := astutil.Unparen(lenExpr).(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return nil, nil
+	}
+	if builtin, ok := r.info.Uses[identOf(call.Fun)].(*types.Builtin); !ok || builtin.Name() != "len" {
+		return nil, nil
+	}
+	ident, ok := astutil.Unparen(call.Args[0]).(*ast.Ident)
+	if !ok {
+		return nil, nil
+	}
+	result, ok := r.mockResults[r.info.Uses[ident]]
+	if !ok {
+		return nil, nil
+	}
+	tv, ok := r.info.Types[n]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.Int {
+		return nil, nil
+	}
+	whenEmpty := constant.Compare(constant.MakeInt64(0), op, tv.Value)
+	whenNonEmpty := constant.Compare(constant.MakeInt64(1), op, tv.Value)
+	if whenEmpty == whenNonEmpty {
+		return nil, nil
+	}
+	t := r.info.TypeOf(ident)
+	nonEmpty, ok := r.nonEmptyValue(t)
+	if !ok {
+		return nil, nil
+	}
+	empty := r.zeroValue(t)
+	if whenEmpty {
+		return result.returns(empty), result.returns(nonEmpty)
+	}
+	return result.returns(nonEmpty), result.returns(empty)
+}
+
+// constCheckReturns 整数・文字列の戻り値と定数の比較(例: x > 0, "admin" == x)の条件式から、mock化するメソッドが返す値を決める
+// 定数・定数の前後の値・空の値から、条件式を満たす値と満たさない値を選ぶ
+func (r *depResolver) constCheckReturns(src *ast.BinaryExpr) (take, avoid mockReturns, ok bool) {
+	switch src.Op {
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+	default:
+		return nil, nil, false
+	}
+	x, y, op := src.X, src.Y, src.Op
+	if _, isIdent := astutil.Unparen(x).(*ast.Ident); !isIdent {
+		x, y = y, x
+		switch op {
+		case token.LSS:
+			op = token.GTR
+		case token.LEQ:
+			op = token.GEQ
+		case token.GTR:
+			op = token.LSS
+		case token.GEQ:
+			op = token.LEQ
+		}
+	}
+	ident, isIdent := astutil.Unparen(x).(*ast.Ident)
+	if !isIdent {
+		return nil, nil, false
+	}
+	result, ok := r.mockResults[r.info.Uses[ident]]
+	if !ok {
+		return nil, nil, false
+	}
+	tv, ok := r.info.Types[y]
+	if !ok || tv.Value == nil {
+		return nil, nil, false
+	}
+	var candidates []constant.Value
+	t := r.info.TypeOf(ident)
+	basic, ok := t.Underlying().(*types.Basic)
+	switch {
+	case !ok:
+		return nil, nil, false
+	case basic.Info()&types.IsInteger != 0 && tv.Value.Kind() == constant.Int:
+		one := constant.MakeInt64(1)
+		candidates = []constant.Value{
+			tv.Value,
+			constant.BinaryOp(tv.Value, token.ADD, one),
+			constant.BinaryOp(tv.Value, token.SUB, one),
+		}
+	case basic.Info()&types.IsString != 0 && tv.Value.Kind() == constant.String:
+		candidates = []constant.Value{
+			tv.Value,
+			constant.MakeString(constant.StringVal(tv.Value) + "a"),
+			constant.MakeString(""),
+		}
+	default:
+		return nil, nil, false
+	}
+	var takeValue, avoidValue constant.Value
+	for _, candidate := range candidates {
+		if constant.Compare(candidate, op, tv.Value) {
+			if takeValue == nil {
+				takeValue = candidate
+			}
+		} else if avoidValue == nil {
+			avoidValue = candidate
+		}
+	}
+	if takeValue == nil || avoidValue == nil {
+		return nil, nil, false
+	}
+	return result.returns(r.constValue(t, takeValue)), result.returns(r.constValue(t, avoidValue)), true
+}
+
+// constValue 定数をmock化するメソッドが返す値の式にする
+// gomockは戻り値の型を確認するため、int・string以外の型(例: int64, 名前付きの型)の場合は型変換する
+func (r *depResolver) constValue(t types.Type, value constant.Value) string {
+	if types.Identical(t, types.Typ[types.Int]) || types.Identical(t, types.Typ[types.String]) {
+		return value.ExactString()
+	}
+	return types.TypeString(t, packageQualifier(r.pkg)) + "(" + value.ExactString() + ")"
+}
+
+// returns mock化するメソッドの戻り値に、指定した値を返すmockReturns
+func (m *mockResult) returns(value string) mockReturns {
+	return mockReturns{m.call: {m.index: value}}
+}
+
+// isNil 式がnilか否か
+func (r *depResolver) isNil(src ast.Expr) bool {
+	ident, ok := src.(*ast.Ident)
+	return ok && r.info.Uses[ident] == types.Universe.Lookup("nil")
+}
+
+// identOf 式が識別子の場合にその識別子を返す(それ以外の場合はnil)
+func identOf(src ast.Expr) *ast.Ident {
+	ident, _ := astutil.Unparen(src).(*ast.Ident)
+	return ident
+}
+
+// isBoolType bool型(bool型を基にした型を含む)か否か
+func isBoolType(src types.Type) bool {
+	if src == nil {
+		return false
+	}
+	basic, ok := src.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsBoolean != 0
+}
+
+// zeroResults メソッドの各戻り値のゼロ値の式
+func (r *depResolver) zeroResults(src types.Type) []string {
+	sig, ok := src.(*types.Signature)
+	if !ok {
+		return nil
+	}
+	values := make([]string, 0, sig.Results().Len())
+	for i := 0; i < sig.Results().Len(); i++ {
+		values = append(values, r.zeroValue(sig.Results().At(i).Type()))
+	}
+	return values
+}
+
+// zeroValue 型のゼロ値の式(例: false, "", 0, time.Time{}, nil)
+func (r *depResolver) zeroValue(src types.Type) string {
+	switch u := src.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		case u.Info()&types.IsString != 0:
+			return `""`
+		case u.Info()&types.IsNumeric != 0:
+			return "0"
+		}
+	case *types.Struct, *types.Array:
+		return types.TypeString(src, packageQualifier(r.pkg)) + "{}"
+	}
+	return "nil"
+}
+
+// nonNilValue nilと比較できる型の、nilではない値の式
+// errorはmockが返すテスト用のエラーにする(その他のインタフェースと関数は値を決められない)
+func (r *depResolver) nonNilValue(src types.Type) (string, bool) {
+	if isErrorType(src) {
+		return MockErrName, true
+	}
+	qualifier := packageQualifier(r.pkg)
+	switch u := src.Underlying().(type) {
+	case *types.Pointer:
+		return "new(" + types.TypeString(u.Elem(), qualifier) + ")", true
+	case *types.Slice, *types.Map:
+		return types.TypeString(src, qualifier) + "{}", true
+	case *types.Chan:
+		return "make(" + types.TypeString(src, qualifier) + ")", true
+	}
+	return "", false
+}
+
+// nonEmptyValue lenで長さを確認できる型の、要素を1つ持つ値の式
+func (r *depResolver) nonEmptyValue(src types.Type) (string, bool) {
+	qualifier := packageQualifier(r.pkg)
+	switch u := src.Underlying().(type) {
+	case *types.Basic:
+		if u.Info()&types.IsString != 0 {
+			return `"a"`, true
+		}
+	case *types.Slice:
+		return types.TypeString(src, qualifier) + "{" + r.zeroValue(u.Elem()) + "}", true
+	case *types.Map:
+		return types.TypeString(src, qualifier) + "{" + r.zeroValue(u.Key()) + ": " + r.zeroValue(u.Elem()) + "}", true
+	}
+	return "", false
+}
+
+// successMockReturns テスト対象のメソッドの正常系のテストケースで、mock化するメソッドが返す値
+// 呼び出し先のテスト対象のメソッドも辿り、呼び出し元のテストケースで正常系を通るようにする
+func successMockReturns(name string, src map[string][]*TestCase, visited map[string]bool) mockReturns {
+	testCases := src[name]
+	if visited[name] || len(testCases) == 0 {
+		return nil
+	}
+	visited[name] = true
+	successPattern := testCases[len(testCases)-1]
+	returns := mergeMockReturns(successPattern.mockReturns)
+	for _, depMethod := range successPattern.depMethods {
+		if method, ok := depMethod.(*TargetMethod); ok {
+			returns = mergeMockReturns(successMockReturns(method.Name, src, visited), returns)
+		}
+	}
+	return returns
+}
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const conditionSource = `package sample
+
+import "errors"
+
+var ErrNotFound = errors.New("not found")
+
+type Status int
+
+type User struct {
+	Admin bool
+}
+
+type Repository interface {
+	Count() (int, error)
+	Size() (int64, error)
+	Status() (Status, error)
+	Name() (string, error)
+	Items() ([]string, error)
+	Ptr() (*int, error)
+	User() (User, error)
+	Valid() bool
+}
+
+type Service struct {
+	Repo Repository
+}
+
+func (s *Service) Target(limit int) error {
+	%s
+	if %s {
+		return errors.New("branch")
+	}
+	return nil
+}
+`
+
+func TestConditionReturns(t *testing.T) {
+	tests := []struct {
+		// mock化するメソッドの戻り値をvに代入する文
+		assign string
+		cond   string
+		// 分岐に入るテストケースと、正常系のテストケースでmockが返す値(「メソッド名:戻り値」)
+		wantTake, wantAvoid string
+		// 分岐に入るための値を決められないか
+		wantNotForced bool
+	}{
+		{assign: "v, _ := s.Repo.Ptr()", cond: "v == nil", wantTake: "Ptr:nil,nil", wantAvoid: "Ptr:new(int),nil"},
+		{assign: "v, _ := s.Repo.Items()", cond: "len(v) == 0", wantTake: "Items:nil,nil", wantAvoid: `Items:[]string{""},nil`},
+		{assign: "v := s.Repo.Valid()", cond: "!v", wantTake: "Valid:false", wantAvoid: "Valid:true"},
+		{cond: "s.Repo.Valid()", wantTake: "Valid:true", wantAvoid: "Valid:false"},
+		{assign: "v, _ := s.Repo.Ptr(); ok := s.Repo.Valid()", cond: "v != nil && !ok", wantTake: "Ptr:new(int),nil Valid:false", wantAvoid: "Ptr:nil,nil Valid:false"},
+		{assign: "v, _ := s.Repo.Ptr(); ok := s.Repo.Valid()", cond: "v == nil || ok", wantTake: "Ptr:nil,nil Valid:false", wantAvoid: "Ptr:new(int),nil Valid:false"},
+		{assign: "v, _ := s.Repo.Count()", cond: "v > 0", wantTake: "Count:1,nil", wantAvoid: "Count:0,nil"},
+		{assign: "v, _ := s.Repo.Count()", cond: "v == 3", wantTake: "Count:3,nil", wantAvoid: "Count:4,nil"},
+		{assign: "v, _ := s.Repo.Count()", cond: "10 <= v", wantTake: "Count:10,nil", wantAvoid: "Count:9,nil"},
+		{assign: "v, _ := s.Repo.Count()", cond: "v > 0 && v < 10", wantTake: "Count:9,nil", wantAvoid: "Count:0,nil"},
+		{assign: "v, _ := s.Repo.Size()", cond: "v != 0", wantTake: "Size:int64(1),nil", wantAvoid: "Size:int64(0),nil"},
+		{assign: "v, _ := s.Repo.Status()", cond: "v == 2", wantTake: "Status:Status(2),nil", wantAvoid: "Status:Status(3),nil"},
+		{assign: "v, _ := s.Repo.Name()", cond: `v != ""`, wantTake: `Name:"a",nil`, wantAvoid: `Name:"",nil`},
+		{assign: "v, _ := s.Repo.Name()", cond: `v == "admin"`, wantTake: `Name:"admin",nil`, wantAvoid: `Name:"admina",nil`},
+		{assign: "v, _ := s.Repo.Name()", cond: `v < "b"`, wantTake: `Name:"",nil`, wantAvoid: `Name:"b",nil`},
+		{assign: "_, err := s.Repo.Count()", cond: "errors.Is(err, ErrNotFound)", wantTake: "Count:0,ErrNotFound", wantAvoid: "Count:0,nil"},
+		{assign: "_, err := s.Repo.Count()", cond: "ErrNotFound != err", wantTake: "Count:0,nil", wantAvoid: "Count:0,ErrNotFound"},
+		{assign: "v, _ := s.Repo.Count()", cond: "v > limit", wantTake: "Count:0,nil", wantAvoid: "Count:0,nil", wantNotForced: true},
+		{assign: "v, _ := s.Repo.User()", cond: "v.Admin", wantTake: "User:User{},nil", wantAvoid: "User:User{},nil", wantNotForced: true},
+	}
+	for _, tt := range tests {
+		result, err := analyzeSource(t, fmt.Sprintf(conditionSource, tt.assign, tt.cond), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testCases := CreateTemplateParams(result, "en").TargetMethodTesCasesMap["Target"]
+		if len(testCases) != 2 {
+			t.Errorf("%s: got %d test cases, want 2", tt.cond, len(testCases))
+			continue
+		}
+		if got := returnsOf(testCases[0]); got != tt.wantTake {
+			t.Errorf("return values to enter %s = %s, want %s", tt.cond, got, tt.wantTake)
+		}
+		if got := returnsOf(testCases[1]); got != tt.wantAvoid {
+			t.Errorf("return values to avoid %s = %s, want %s", tt.cond, got, tt.wantAvoid)
+		}
+		reported := false
+		for _, d := range result.Diagnostics {
+			reported = reported || d.Code == "diagnostic.branch_not_forced"
+		}
+		if testCases[0].NotForced != tt.wantNotForced || reported != tt.wantNotForced {
+			t.Errorf("%s: NotForced = %t, diagnostic.branch_not_forced reported = %t, want %t", tt.cond, testCases[0].NotForced, reported, tt.wantNotForced)
+		}
+	}
+}
+
+// returnsOf テストケースでmock化するRepoのメソッドが返す値を、「メソッド名:戻り値」の一覧にする
+func returnsOf(src *UpdateTestCase) string {
+	returns := make([]string, 0, len(src.DepMethodsInField["Repo"]))
+	for _, mockMethod := range src.DepMethodsInField["Repo"] {
+		returns = append(returns, mockMethod.Name+":"+mockMethod.Return)
+	}
+	return strings.Join(returns, " ")
+}
@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// NondeterministicCall テスト対象の関数で呼び出している、実行ごとに結果が変わる関数(例: time.Now)
+type NondeterministicCall struct {
+	// 呼び出している関数(例: time.Now)
+	Func string
+	// 最初に呼び出している位置
+	Position token.Position
+	// テスト対象のメソッドを持つ構造体名(関数の場合は空文字)
+	Struct string
+	// 差し替えられるように注入するフィールド名(例: now), 関数の場合は引数名
+	// 差し替えに利用できるフィールドを構造体が既に持っている場合は、そのフィールド(インタフェースの場合はメソッドまで, 例: Clock.Now)
+	Field string
+	// 注入するフィールドの型(例: func() time.Time)
+	FieldType string
+	// 構造体が既に持っているフィールドか否か
+	Existing bool
+}
+
+// nondeterministicFunc 実行ごとに結果が変わる関数
+type nondeterministicFunc struct {
+	// 対象とする関数名(空の場合はパッケージの全ての関数)
+	names []string
+	// 対象とする関数名の接頭辞
+	prefixes []string
+	// 対象外とする関数名の接頭辞(例: 乱数の生成器を作成するrand.New)
+	excludes []string
+	// 注入するフィールド名と型(空の場合は関数名と関数の型から決める)
+	field, fieldType string
+}
+
+// nondeterministicFuncs パッケージごとの、実行ごとに結果が変わる関数
+var nondeterministicFuncs = map[string]nondeterministicFunc{
+	"time":                       {names: []string{"Now", "Since", "Until"}, field: "now", fieldType: "func() time.Time"},
+	"math/rand":                  {excludes: []string{"New", "Seed"}, field: "rand", fieldType: "*rand.Rand"},
+	"math/rand/v2":               {excludes: []string{"New"}, field: "rand", fieldType: "*rand.Rand"},
+	"crypto/rand":                {field: "randReader", fieldType: "io.Reader"},
+	"os":                         {names: []string{"Getenv", "LookupEnv", "Environ", "Hostname"}},
+	"github.com/google/uuid":     {prefixes: []string{"New"}, field: "newUUID"},
+	"github.com/gofrs/uuid":      {prefixes: []string{"New"}, field: "newUUID"},
+	"github.com/gofrs/uuid/v5":   {prefixes: []string{"New"}, field: "newUUID"},
+	"github.com/satori/go.uuid":  {prefixes: []string{"New"}, field: "newUUID"},
+	"github.com/oklog/ulid":      {names: []string{"Make", "New", "Now"}, field: "newULID"},
+	"github.com/oklog/ulid/v2":   {names: []string{"Make", "New", "Now"}, field: "newULID"},
+	"github.com/rs/xid":          {names: []string{"New"}, field: "newXID"},
+	"github.com/segmentio/ksuid": {names: []string{"New"}, field: "newKSUID"},
+}
+
+// match 関数名が対象か否か
+func (f nondeterministicFunc) match(name string) bool {
+	for _, exclude := range f.excludes {
+		if strings.HasPrefix(name, exclude) {
+			return false
+		}
+	}
+	if len(f.names) == 0 && len(f.prefixes) == 0 {
+		return true
+	}
+	for _, n := range f.names {
+		if n == name {
+			return true
+		}
+	}
+	for _, prefix := range f.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// nondeterministicCall 呼び出し式が実行ごとに結果が変わる関数の呼び出しの場合に、その情報を返す
+func (r *depResolver) nondeterministicCall(src *ast.CallExpr, fset *token.FileSet) (*NondeterministicCall, bool) {
+	if r.info == nil {
+		return nil, false
+	}
+	fun, ok := astutil.Unparen(src.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return nil, false
+	}
+	fn, ok := r.info.Uses[fun.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Type().(*types.Signature).Recv() != nil {
+		return nil, false
+	}
+	nf, ok := nondeterministicFuncs[fn.Pkg().Path()]
+	if !ok || !nf.match(fn.Name()) {
+		return nil, false
+	}
+	call := &NondeterministicCall{
+		Func:      fn.Pkg().Name() + "." + fn.Name(),
+		Position:  fset.Position(src.Pos()),
+		Field:     nf.field,
+		FieldType: nf.fieldType,
+	}
+	if call.Field == "" {
+		call.Field = strings.ToLower(fn.Name()[:1]) + fn.Name()[1:]
+	}
+	if call.FieldType == "" {
+		call.FieldType = types.TypeString(fn.Type(), packageQualifier(r.pkg))
+	}
+	if r.recv == nil {
+		return call, true
+	}
+	recvType := r.recv.Type()
+	if ptr, ok := recvType.(*types.Pointer); ok {
+		recvType = ptr.Elem()
+	}
+	if named, ok := recvType.(*types.Named); ok {
+		call.Struct = named.Obj().Name()
+	}
+	if field, ok := injectableField(recvType, fn, call.Field, call.FieldType, packageQualifier(r.pkg)); ok {
+		call.Field, call.Existing = field, true
+	}
+	return call, true
+}
+
+// injectableField 構造体が、関数の代わりに利用できるフィールドを持つ場合に、そのフィールドを返す
+// 注入するフィールドの型(fieldType)もしくは関数と同じ型のフィールド(例: now func() time.Time)か、
+// 関数もしくは注入するフィールドと同じ名前と型のメソッドを持つインタフェースのフィールド(例: time.Sinceに対するClock.Now)
+func injectableField(src types.Type, fn *types.Func, fieldName, fieldType string, qualifier types.Qualifier) (string, bool) {
+	st, ok := src.Underlying().(*types.Struct)
+	if !ok {
+		return "", false
+	}
+	sig := fn.Type().(*types.Signature)
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if types.TypeString(field.Type(), qualifier) == fieldType {
+			return field.Name(), true
+		}
+		if fieldSig, ok := field.Type().Underlying().(*types.Signature); ok && types.Identical(fieldSig, sig) {
+			return field.Name(), true
+		}
+		iface, ok := field.Type().Underlying().(*types.Interface)
+		if !ok {
+			continue
+		}
+		for j := 0; j < iface.NumMethods(); j++ {
+			method := iface.Method(j)
+			methodSig := method.Type().(*types.Signature)
+			methodType := types.NewSignatureType(nil, nil, nil, methodSig.Params(), methodSig.Results(), methodSig.Variadic())
+			if method.Name() == fn.Name() && types.Identical(methodType, sig) {
+				return field.Name() + "." + method.Name(), true
+			}
+			if strings.EqualFold(method.Name(), fieldName) && types.TypeString(methodType, qualifier) == fieldType {
+				return field.Name() + "." + method.Name(), true
+			}
+		}
+	}
+	return "", false
+}
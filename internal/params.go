@@ -2,6 +2,7 @@ package internal
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 )
 
@@ -11,6 +12,53 @@ type TemplateParams struct {
 	FieldMap map[string]*FieldInfo
 	// テスト対象メソッドごとのテストケースの情報
 	TargetMethodTesCasesMap map[string][]*UpdateTestCase
+	// テスト対象の関数ごとのmock化する引数の情報
+	ArgFieldMap map[string]map[string]*FieldInfo
+	// 型パラメータを持つテスト対象の関数ごとの、実体化した情報
+	InstantiationMap map[string]*Instantiation
+	// テスト対象の関数ごとの、関数自体の情報
+	MethodInfoMap map[string]*MethodInfo
+	// 解析時に検出した、利用者に伝えるべき内容の一覧
+	Diagnostics []*Diagnostic
+	// テストケース名などに利用する言語(ja, en)
+	Lang string
+	// Langでのメッセージのキーと書式の組み合わせ(例: index .TemplateParams.Messages "testcase.success")
+	Messages map[string]string
+	// テストの結果の比較に利用するライブラリ(Assertion*のいずれか)
+	Assertion string
+	// テスト関数に加えて、ベンチマーク関数を生成するか
+	Bench bool
+	// テスト関数に加えて、fuzzテスト関数を生成するか
+	Fuzz bool
+}
+
+// テストの結果の比較に利用するライブラリ
+const (
+	// AssertionAssert testify/assert(比較に失敗してもテストケースを続ける)
+	AssertionAssert = "assert"
+	// AssertionRequire testify/require(比較に失敗した時点でテストケースを終了する)
+	AssertionRequire = "require"
+	// AssertionCmp github.com/google/go-cmpのcmp.Diffとt.Errorf
+	AssertionCmp = "cmp"
+	// AssertionStd 標準ライブラリのreflect.DeepEqual・errors.Isとt.Errorf
+	AssertionStd = "std"
+)
+
+// IsValidAssertion 対応しているテストの結果の比較に利用するライブラリか否か
+func IsValidAssertion(assertion string) bool {
+	switch assertion {
+	case AssertionAssert, AssertionRequire, AssertionCmp, AssertionStd:
+		return true
+	}
+	return false
+}
+
+// NormalizeAssertion 対応していない場合は、testify/assertを返す
+func NormalizeAssertion(assertion string) string {
+	if IsValidAssertion(assertion) {
+		return assertion
+	}
+	return AssertionAssert
 }
 
 func (t *TemplateParams) ToJson() ([]byte, error) {
@@ -21,10 +69,30 @@ func (t *TemplateParams) ToJson() ([]byte, error) {
 type UpdateTestCase struct {
 	// テストケースの分岐点となる行数
 	Line int
+	// テストケースの分岐点となるif文の条件式(正常系とコンテキストのキャンセルの場合は空文字)
+	Condition string
+	// テストケース名(同じテスト対象の関数の中で一意になる)
+	Name string
 	// 正常系のテストケースか否か
 	IsSuccessPattern bool
+	// コンテキストがキャンセルされた場合のテストケースか否か
+	IsCancelPattern bool
+	// テストケースで期待するエラー
+	WantErr *WantErr
+	// テストケースで期待するステータスコードの式(例: http.StatusBadRequest, HTTPハンドラーではない場合と決められない場合は空文字)
+	WantStatus string
+	// テストケースで期待するgRPCのステータスコードの式(例: codes.NotFound, gRPCのサービスのメソッドではない場合と決められない場合は空文字)
+	WantCode string
+	// テストケースの分岐までに呼び出している、実行ごとに結果が変わる関数(例: time.Now)
+	Nondeterministic []string
+	// 分岐に入るためにmock化するメソッドが返す値を、条件式から決められなかったか(TODOコメントを出力する)
+	NotForced bool
+	// mock化するメソッドが返す、テスト用のエラーの変数名(利用しない場合は空文字)
+	MockErr string
 	// テストケース内で利用されている各フィールドのメソッド群
 	DepMethodsInField map[string][]*TemplateMockMethod
+	// テストケース内で利用されている各引数のメソッド群
+	DepMethodsInArg map[string][]*TemplateMockMethod
 }
 
 // TemplateMockMethod テンプレートのパラメータ用のmock化するメソッド
@@ -33,46 +101,63 @@ type TemplateMockMethod struct {
 	Name string
 	// ASTにおけるメソッドの位置
 	Position int
-	// 引数(nil*引数の数)
+	// 引数(各引数の型のゼロ値, context.Contextの引数はgomock.Any(), sqlmockの場合は期待するSQL)
 	Arg string
-	// 戻り値(nil*戻り値の数)
+	// 全ての引数をgomock.Any()にした引数(引数の値を決められないfuzzテストで利用する)
+	AnyArg string
+	// 戻り値(各戻り値のゼロ値, テストケースの分岐に入るために必要な戻り値はその値にする)
+	// sqlmockの場合は、返す値を指定するメソッドの呼び出し(例: .WillReturnRows(sqlmock.NewRows(nil)))
 	Return string
 }
 
 // CreateTemplateParams テスト対象のファイルから抽出した情報(*TestFile)を元にテンプレートのパラメータを返す
-func CreateTemplateParams(t *TestFile) *TemplateParams {
+// lang: テストケース名や利用者に伝えるべき内容に利用する言語
+func CreateTemplateParams(t *TestFile, lang string) *TemplateParams {
 	resolvedTargetMethods := make(map[string][]*MockMethod)
 
 	v := new(TemplateParams)
 	v.FieldMap = t.FieldMap
+	v.Lang = NormalizeLang(lang)
+	v.Messages = Messages(v.Lang)
+	v.Diagnostics = t.Diagnostics
+	for _, d := range v.Diagnostics {
+		d.localize(v.Lang)
+	}
+	v.ArgFieldMap = t.ArgFieldMap
+	v.InstantiationMap = t.InstantiationMap
+	v.MethodInfoMap = t.MethodInfoMap
 	v.TargetMethodTesCasesMap = make(map[string][]*UpdateTestCase, len(t.TargetMethodTesCasesMap))
 	for targetMethodName, methodTestCases := range t.TargetMethodTesCasesMap {
+		usedNames := make(map[string]int, len(methodTestCases))
 		for _, testCase := range methodTestCases {
 			uTestCase := new(UpdateTestCase)
 			uTestCase.Line = testCase.Line
+			uTestCase.Condition = testCase.Condition
+			uTestCase.Name = createTestCaseName(testCase, v.Lang, usedNames)
 			uTestCase.IsSuccessPattern = testCase.IsSuccessPattern
+			uTestCase.IsCancelPattern = testCase.IsCancelPattern
+			uTestCase.WantErr = testCase.WantErr
+			uTestCase.WantStatus = testCase.WantStatus
+			uTestCase.WantCode = testCase.WantCode
+			uTestCase.Nondeterministic = testCase.Nondeterministic
+			uTestCase.NotForced = testCase.NotForced
 			uTestCase.DepMethodsInField = map[string][]*TemplateMockMethod{}
+			uTestCase.DepMethodsInArg = map[string][]*TemplateMockMethod{}
 			for _, depMethod := range testCase.depMethods {
 				switch method := depMethod.(type) {
 				case *TargetMethod:
+					// 呼び出し先のテスト対象のメソッドは、正常系を通るようにする
+					returns := mergeMockReturns(successMockReturns(method.Name, t.TargetMethodTesCasesMap, map[string]bool{}), testCase.mockReturns)
 					mockMethods, ok := resolvedTargetMethods[method.Name]
 					if ok {
-						inputTemplateMockMethods(mockMethods, uTestCase.DepMethodsInField)
+						inputTemplateMockMethods(mockMethods, uTestCase, returns)
 					} else {
 						resolvedMockMethods := resolveToMockMethods([]IFDepMethod{method}, t.TargetMethodTesCasesMap, resolvedTargetMethods)
 						resolvedTargetMethods[method.Name] = resolvedMockMethods
-						inputTemplateMockMethods(resolvedMockMethods, uTestCase.DepMethodsInField)
+						inputTemplateMockMethods(resolvedMockMethods, uTestCase, returns)
 					}
 				case *MockMethod:
-					uTestCase.DepMethodsInField[method.Field] = append(
-						uTestCase.DepMethodsInField[method.Field],
-						&TemplateMockMethod{
-							Name:     method.Name,
-							Position: int(method.Position),
-							Arg:      createNumberOfNilString(method.ArgLen),
-							Return:   createNumberOfNilString(method.ReturnLen),
-						},
-					)
+					inputTemplateMockMethods([]*MockMethod{method}, uTestCase, testCase.mockReturns)
 				}
 			}
 			v.TargetMethodTesCasesMap[targetMethodName] = append(v.TargetMethodTesCasesMap[targetMethodName], uTestCase)
@@ -81,18 +166,57 @@ func CreateTemplateParams(t *TestFile) *TemplateParams {
 	return v
 }
 
+// createTestCaseName テストケース名を作成する
+// 行数に依存しない名前にするため、if文の分岐のテストケースは条件式から名前を作成する
+// usedNames: 同じテスト対象の関数で作成済みの名前と、その数(同じ名前になる場合は連番を付与する)
+func createTestCaseName(src *TestCase, lang string, usedNames map[string]int) string {
+	var name string
+	switch {
+	case src.IsSuccessPattern:
+		name = Localize(lang, "testcase.success")
+	case src.IsCancelPattern:
+		name = Localize(lang, "testcase.cancel")
+	case src.nameKey != "":
+		name = Localize(lang, "testcase.branch", Localize(lang, src.nameKey, src.nameArgs...))
+	default:
+		name = Localize(lang, "testcase.branch", src.Condition)
+	}
+	usedNames[name]++
+	if count := usedNames[name]; count > 1 {
+		name = fmt.Sprintf("%s (%d)", name, count)
+	}
+	return name
+}
+
 // inputTemplateMockMethods mockメソッド一覧をテンプレートのパラメータに変換して格納する
-func inputTemplateMockMethods(src []*MockMethod, dest map[string][]*TemplateMockMethod) {
+// フィールドのメソッドと引数のメソッドは、それぞれ別に格納する
+// returns: テストケースの分岐に入るために、mock化するメソッドが返す値
+func inputTemplateMockMethods(src []*MockMethod, dest *UpdateTestCase, returns mockReturns) {
 	for _, mockMethod := range src {
-		dest[mockMethod.Field] = append(
-			dest[mockMethod.Field],
-			&TemplateMockMethod{
-				Name:     mockMethod.Name,
-				Position: int(mockMethod.Position),
-				Arg:      createNumberOfNilString(mockMethod.ArgLen),
-				Return:   createNumberOfNilString(mockMethod.ReturnLen),
-			},
-		)
+		destMap, key := dest.DepMethodsInField, mockMethod.Field
+		if mockMethod.Param != "" {
+			destMap, key = dest.DepMethodsInArg, mockMethod.Param
+		}
+		templateMockMethod := &TemplateMockMethod{
+			Name:     mockMethod.Name,
+			Position: int(mockMethod.Position),
+			Arg:      createArgString(mockMethod),
+			AnyArg:   createAnyArgString(mockMethod.ArgLen),
+			Return:   createReturnString(mockMethod, returns[mockMethod.Position]),
+		}
+		if mockMethod.SQLExpect != "" {
+			// sqlmockでは、期待する呼び出しのメソッドにSQLを渡し、返す値をWillReturnXxxで指定する
+			templateMockMethod.Name = mockMethod.SQLExpect
+			templateMockMethod.Arg = mockMethod.SQL
+			templateMockMethod.AnyArg = mockMethod.SQL
+			templateMockMethod.Return = createSQLReturnString(mockMethod, returns[mockMethod.Position])
+		}
+		destMap[key] = append(destMap[key], templateMockMethod)
+		for _, value := range returns[mockMethod.Position] {
+			if value == MockErrName {
+				dest.MockErr = MockErrName
+			}
+		}
 	}
 }
 
@@ -121,12 +245,53 @@ func resolveToMockMethods(src []IFDepMethod, targetMethodTestCaseMap map[string]
 			results = append(results, targetMethodMockMethods...)
 			dest[method.Name] = targetMethodMockMethods
 		case *MockMethod:
+			// 呼び出し先の引数のメソッドは、呼び出し元のテストケースでは差し替えられない
+			if method.Param != "" {
+				continue
+			}
 			results = append(results, method)
 		}
 	}
 	return results
 }
 
+// createArgString mockメソッドの引数の初期値の文字列を作成する
+// 引数の値を解析できなかった場合は、どの値でも一致するようにgomock.Any()にする
+func createArgString(src *MockMethod) string {
+	if len(src.ArgValues) != src.ArgLen {
+		return createAnyArgString(src.ArgLen)
+	}
+	return strings.Join(src.ArgValues, ",")
+}
+
+// createAnyArgString 指定数のgomock.Any()の文字列を作成する
+// 引数の値を決められないfuzzテストで、どの引数でも一致するようにする
+func createAnyArgString(num int) string {
+	args := make([]string, 0, num)
+	for i := 0; i < num; i++ {
+		args = append(args, anyArg)
+	}
+	return strings.Join(args, ",")
+}
+
+// createReturnString mockメソッドの戻り値の初期値の文字列を作成する
+// 各戻り値のゼロ値を基に、テストケースの分岐に入るために必要な戻り値(values)を差し替える
+// 型情報がない場合は、nil*戻り値の数にする
+func createReturnString(src *MockMethod, values map[int]string) string {
+	if len(src.ReturnValues) != src.ReturnLen {
+		return createNumberOfNilString(src.ReturnLen)
+	}
+	results := make([]string, 0, src.ReturnLen)
+	for i, zero := range src.ReturnValues {
+		if value, ok := values[i]; ok {
+			results = append(results, value)
+			continue
+		}
+		results = append(results, zero)
+	}
+	return strings.Join(results, ",")
+}
+
 // createNumberOfNilString 指定数のnilの文字列を作成する
 // mockメソッドの引数と戻り値の初期値を埋めるのに利用する
 func createNumberOfNilString(num int) string {
@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testCaseNameSource = `package sample
+
+import (
+	"context"
+	"errors"
+)
+
+type Repository interface {
+	Find(ctx context.Context, id int) (string, error)
+	Exists(ctx context.Context, id int) bool
+}
+
+type Service struct {
+	Repo Repository
+}
+
+func (s *Service) Name(ctx context.Context, id int) (string, error) {
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	if !s.Repo.Exists(ctx, id) {
+		return "", errors.New("not found")
+	}
+	name, err := s.Repo.Find(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if len(name) == 0 {
+		return "", errors.New("empty")
+	}
+	if len(name) == 0 {
+		return "", errors.New("empty")
+	}
+	return name, nil
+}
+
+func (s *Service) Wait(ctx context.Context, done <-chan struct{}) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+		return nil
+	}
+}
+`
+
+// TestCreateTemplateParams_testCaseNames 解析したif文の条件式から、行数に依存しないテストケース名を作成することを確認する
+func TestCreateTemplateParams_testCaseNames(t *testing.T) {
+	result, err := analyzeSource(t, testCaseNameSource, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string][]string{
+		"en": {"error: context canceled", "Exists is false", "Find returns error", "len(name) == 0", "len(name) == 0 (2)", "success"},
+		"ja": {"異常: コンテキストのキャンセル", "異常: Existsがfalse", "異常: Findがerrorを返す", "異常: len(name) == 0", "異常: len(name) == 0 (2)", "正常"},
+	}
+	for lang, want := range tests {
+		got := make([]string, 0, len(want))
+		for _, testCase := range CreateTemplateParams(result, lang).TargetMethodTesCasesMap["Name"] {
+			got = append(got, testCase.Name)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("test case names in %s = %q, want %q", lang, got, want)
+		}
+	}
+
+	// 依存しているメソッドを呼び出していなくても、selectでキャンセルを確認している場合はキャンセルのテストケースを生成する
+	got := make([]string, 0, 2)
+	for _, testCase := range CreateTemplateParams(result, "en").TargetMethodTesCasesMap["Wait"] {
+		got = append(got, testCase.Name)
+	}
+	if want := []string{"error: context canceled", "success"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("test case names of Wait = %q, want %q", got, want)
+	}
+}
@@ -1,29 +1,47 @@
 package internal
 
 import (
-	"errors"
+	"fmt"
 	"go/ast"
 	"go/token"
 	"go/types"
+	"sort"
 	"strings"
 
+	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/go/ast/inspector"
 )
 
 // GetAnalysisResult ASTから値を抽出し、テンプレートのパラメータ用の構造体を生成する
-func GetAnalysisResult(astF *ast.File, fset *token.FileSet, packageTypes *types.Package) (*TestFile, error) {
+// typeArgs: 型パラメータ名と、テストで利用する具体的な型の組み合わせ(指定がない型パラメータは制約から選択する)
+func GetAnalysisResult(astF *ast.File, fset *token.FileSet, packageTypes *types.Package, info *types.Info, typeArgs map[string]string) (*TestFile, error) {
 	v := new(TestFile)
-	targetStructName, abbreviationName, err := extractTargetStructName(astF)
+	targetStructName, _, err := extractTargetStructName(fset, astF)
 	if err != nil {
 		return nil, err
 	}
-	fm, err := extractTargetStructInfo(packageTypes, targetStructName)
+	typeArgResolver := newTypeArgResolver(fset, packageTypes, astF, typeArgs)
+	v.FieldMap = map[string]*FieldInfo{}
+	var recvType *types.Named
+	// レシーバーを持つメソッドがない場合は、関数のみがテスト対象になる
+	if targetStructName != "" {
+		var fm map[string]*FieldInfo
+		var diagnostics []*Diagnostic
+		fm, diagnostics, recvType, err = extractTargetStructInfo(fset, packageTypes, targetStructName, typeArgResolver)
+		if err != nil {
+			return nil, err
+		}
+		v.FieldMap = fm
+		v.Diagnostics = diagnostics
+	}
+	v.InstantiationMap, err = extractInstantiations(astF, info, recvType, targetStructName, typeArgResolver)
 	if err != nil {
 		return nil, err
 	}
-	v.FieldMap = fm
+	v.Diagnostics = append(v.Diagnostics, typeArgResolver.diagnostics...)
 	inspect := inspector.New([]*ast.File{astF})
-	v.TargetMethodTesCasesMap = extractTargetMethodTestCasesMap(fset, inspect, abbreviationName, targetStructName, fm)
+	extractTargetMethodTestCasesMap(fset, inspect, targetStructName, newDepResolver(info, packageTypes, v.FieldMap), v)
+	instantiateArgFieldMap(v.ArgFieldMap, v.InstantiationMap, packageTypes)
 	return v, nil
 }
 
@@ -31,7 +49,7 @@ func GetAnalysisResult(astF *ast.File, fset *token.FileSet, packageTypes *types.
 // 戻り値
 // structName: 構造体名
 // abbreviationName: メソッドのレシーバー名
-func extractTargetStructName(src *ast.File) (structName, abbreviationName string, err error) {
+func extractTargetStructName(fset *token.FileSet, src *ast.File) (structName, abbreviationName string, err error) {
 	recvTypeNameMap := make(map[string]string)
 	for _, decl := range src.Decls {
 		fDecl, ok := decl.(*ast.FuncDecl)
@@ -41,16 +59,21 @@ func extractTargetStructName(src *ast.File) (structName, abbreviationName string
 		if fDecl.Recv == nil {
 			continue
 		}
-		abbreviation := fDecl.Recv.List[0].Names[0].Name
+		// レシーバー名を省略したメソッド(例: func (Service) M())の場合は空文字
+		var abbreviation string
+		if len(fDecl.Recv.List[0].Names) != 0 {
+			abbreviation = fDecl.Recv.List[0].Names[0].Name
+		}
 		var recvTypeName string
 		recvTypeName, err = extractRecvTypeName(fDecl.Recv.List[0].Type)
 		if err != nil {
+			setErrorPosition(err, fset.Position(fDecl.Recv.Pos()))
 			return "", "", err
 		}
 		recvTypeNameMap[recvTypeName] = abbreviation
-	}
-	if len(recvTypeNameMap) != 1 {
-		return "", "", errors.New("テスト対象のメソッドを持つ構造体が一意に定まりません")
+		if len(recvTypeNameMap) > 1 {
+			return "", "", NewError("error.struct_not_unique").at(fset.Position(fDecl.Recv.Pos()))
+		}
 	}
 	for k, v := range recvTypeNameMap {
 		return k, v, nil
@@ -59,290 +82,594 @@ func extractTargetStructName(src *ast.File) (structName, abbreviationName string
 }
 
 // extractTargetStructInfo テスト対象のメソッドを持つ構造体の情報(フィールド)を抽出する
-func extractTargetStructInfo(packageTypes *types.Package, targetStructName string) (fieldMap map[string]*FieldInfo, err error) {
+// 構造体が型パラメータを持つ場合は、具体的な型で実体化した構造体のフィールドを抽出する
+func extractTargetStructInfo(fset *token.FileSet, packageTypes *types.Package, targetStructName string, typeArgResolver *typeArgResolver) (fieldMap map[string]*FieldInfo, diagnostics []*Diagnostic, recvType *types.Named, err error) {
 	if packageTypes.Scope() == nil {
-		err = errors.New("構造体の型情報を読み取れていません")
+		err = NewError("error.scope_not_found")
 		return
 	}
 	structObj := packageTypes.Scope().Lookup(targetStructName)
 	if structObj == nil {
-		err = errors.New("対象の構造体の情報を読み取れていません")
+		err = NewError("error.struct_not_found")
+		return
+	}
+	recvType, ok := structObj.Type().(*types.Named)
+	if !ok {
+		err = NewError("error.not_struct").at(fset.Position(structObj.Pos()))
 		return
 	}
-	structUnderLyingType, ok := structObj.Type().Underlying().(*types.Struct)
+	recvType, err = typeArgResolver.instantiateStruct(recvType)
+	if err != nil {
+		return
+	}
+	structUnderLyingType, ok := recvType.Underlying().(*types.Struct)
 	if !ok {
-		err = errors.New("読み取った構造体の型は構造体ではありません")
+		err = NewError("error.not_struct").at(fset.Position(structObj.Pos()))
 		return
 	}
-	fieldMap = make(map[string]*FieldInfo, structUnderLyingType.NumFields())
-	for i := 0; i < structUnderLyingType.NumFields(); i++ {
-		field := structUnderLyingType.Field(i)
-		typeName := field.Type().String()
-		typeNameIndex := strings.LastIndex(typeName, "/") + 1
-		if strings.Contains(typeName, "command-line-arguments.") {
-			typeNameIndex += len("command-line-arguments.")
+	c := &fieldCollector{
+		fset:       fset,
+		pkg:        packageTypes,
+		structName: targetStructName,
+		visited:    map[types.Type]bool{structObj.Type(): true},
+		fieldMap:   make(map[string]*FieldInfo, structUnderLyingType.NumFields()),
+	}
+	c.collect(structUnderLyingType, "")
+	return c.fieldMap, c.diagnostics, recvType, nil
+}
+
+// fieldCollector 構造体のフィールド情報を収集する
+type fieldCollector struct {
+	fset *token.FileSet
+	// テスト対象のパッケージ
+	pkg *types.Package
+	// テスト対象のメソッドを持つ構造体名
+	structName string
+	// 循環して辿らないように、辿っている途中の構造体を記録する
+	visited map[types.Type]bool
+	// 収集したフィールド情報
+	fieldMap map[string]*FieldInfo
+	// 収集中に検出した、利用者に伝えるべき内容
+	diagnostics []*Diagnostic
+}
+
+// collect 構造体のフィールド情報を格納する
+// テスト対象のパッケージで定義された構造体型のフィールドはネストしたフィールドも辿り、「親.子」のパスで格納する
+func (c *fieldCollector) collect(src *types.Struct, parentPath string) {
+	for i := 0; i < src.NumFields(); i++ {
+		field := src.Field(i)
+		path := joinFieldPath(parentPath, field.Name())
+		fieldInfo := createFieldInfo(field.Type(), c.pkg)
+		fieldInfo.IsNested = parentPath != ""
+		if fieldInfo.SQLDB != "" && fieldInfo.IsNested {
+			// ネストしたフィールドはテストケースから差し替えられないため、sqlmockの対象にしない
+			fieldInfo.SQLDB, fieldInfo.IsConcrete = "", true
 		}
-		typeName = typeName[typeNameIndex:]
-		packageName := ""
-		if packageNameIndex := strings.Index(typeName, "."); packageNameIndex != -1 {
-			packageName = typeName[:packageNameIndex]
-			typeName = typeName[packageNameIndex+1:]
+		if fieldInfo.IsConcrete {
+			c.diagnostics = append(c.diagnostics, newDiagnostic(
+				c.fset.Position(field.Pos()), "diagnostic.concrete_field",
+				c.structName, path, types.TypeString(field.Type(), packageQualifier(c.pkg)),
+			))
 		}
+		c.fieldMap[path] = fieldInfo
 
-		fieldMap[field.Name()] = &FieldInfo{
-			IsInterface:            strings.Contains(field.Type().Underlying().String(), "interface{"),
-			PackageName:            packageName,
-			TypeName:               typeName,
-			UpperCamelCaseTypeName: strings.ToUpper(typeName[0:1]) + typeName[1:],
+		fieldType := field.Type()
+		if ptr, ok := fieldType.Underlying().(*types.Pointer); ok {
+			fieldType = ptr.Elem()
 		}
+		nestedStruct, ok := fieldType.Underlying().(*types.Struct)
+		if !ok || c.visited[fieldType] {
+			continue
+		}
+		if named, ok := fieldType.(*types.Named); ok && named.Obj().Pkg() != c.pkg {
+			continue
+		}
+		c.visited[fieldType] = true
+		c.collect(nestedStruct, path)
+		delete(c.visited, fieldType)
 	}
-	return
+}
+
+// createFieldInfo 型から、フィールドもしくは引数の情報を作成する
+func createFieldInfo(src types.Type, pkg *types.Package) *FieldInfo {
+	typeName := src.String()
+	typeNameIndex := strings.LastIndex(typeName, "/") + 1
+	if strings.Contains(typeName, "command-line-arguments.") {
+		typeNameIndex += len("command-line-arguments.")
+	}
+	typeName = typeName[typeNameIndex:]
+	packageName := ""
+	if packageNameIndex := strings.Index(typeName, "."); packageNameIndex != -1 {
+		packageName = typeName[:packageNameIndex]
+		typeName = typeName[packageNameIndex+1:]
+	}
+
+	fieldInfo := &FieldInfo{
+		IsInterface:            strings.Contains(src.Underlying().String(), "interface{"),
+		PackageName:            packageName,
+		TypeName:               typeName,
+		Type:                   types.TypeString(src, packageQualifier(pkg)),
+		UpperCamelCaseTypeName: strings.ToUpper(typeName[0:1]) + typeName[1:],
+		SQLDB:                  sqlDBKind(src),
+	}
+	fieldInfo.IsConcrete = fieldInfo.SQLDB == "" && isConcreteDependency(src, pkg)
+	if signature, ok := src.Underlying().(*types.Signature); ok {
+		fieldInfo.IsFunc = true
+		fieldInfo.FuncStub = createFuncStub(signature, packageQualifier(pkg))
+	}
+	return fieldInfo
+}
+
+// packageQualifier テスト対象のパッケージの型はパッケージ名を省略し、それ以外はパッケージ名で修飾する
+func packageQualifier(pkg *types.Package) types.Qualifier {
+	return func(other *types.Package) string {
+		if other == pkg {
+			return ""
+		}
+		return other.Name()
+	}
+}
+
+// isConcreteDependency 他パッケージで定義された構造体へのポインタ(例: *http.Client)か否か
+// このようなフィールドは差し替えができないため、mock化の対象にならない
+func isConcreteDependency(src types.Type, pkg *types.Package) bool {
+	ptr, ok := src.Underlying().(*types.Pointer)
+	if !ok {
+		return false
+	}
+	named, ok := ptr.Elem().(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg() == pkg {
+		return false
+	}
+	_, ok = named.Underlying().(*types.Struct)
+	return ok
+}
+
+// createFuncStub 関数型のフィールドに渡す、ゼロ値を返すだけの関数リテラルを作成する
+func createFuncStub(src *types.Signature, qualifier types.Qualifier) string {
+	results := make([]string, 0, src.Results().Len())
+	for i := 0; i < src.Results().Len(); i++ {
+		results = append(results, zeroValueString(src.Results().At(i).Type(), qualifier))
+	}
+	signature := types.TypeString(src, qualifier)
+	if len(results) == 0 {
+		return signature + " {}"
+	}
+	return fmt.Sprintf("%s { return %s }", signature, strings.Join(results, ", "))
+}
+
+// zeroValueString 型のゼロ値を表す式の文字列を返す
+func zeroValueString(src types.Type, qualifier types.Qualifier) string {
+	switch t := src.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case t.Info()&types.IsBoolean != 0:
+			return "false"
+		case t.Info()&types.IsString != 0:
+			if _, ok := src.(*types.Named); ok {
+				return types.TypeString(src, qualifier) + `("")`
+			}
+			return `""`
+		case t.Info()&types.IsNumeric != 0:
+			return "0"
+		}
+		return "nil"
+	case *types.Struct, *types.Array:
+		return types.TypeString(src, qualifier) + "{}"
+	}
+	return "nil"
 }
 
 // extractTargetMethodTestCasesMap 各テスト対象のメソッドにおけるテストケース一覧を抽出する
-func extractTargetMethodTestCasesMap(fset *token.FileSet, inspect *inspector.Inspector, abbreviationName, targetStructName string, fieldMap map[string]*FieldInfo) map[string][]*TestCase {
+// 合わせて、各テスト対象の関数におけるmock化する引数の情報と、関数自体の情報も抽出する
+func extractTargetMethodTestCasesMap(fset *token.FileSet, inspect *inspector.Inspector, targetStructName string, resolver *depResolver, dest *TestFile) {
 	targetMethodTestCaseMap := map[string][]*TestCase{}
-	targetMethodIfPositionsMap := make(map[string][]token.Pos, 0)
+	targetMethodArgFieldMap := map[string]map[string]*FieldInfo{}
+	targetMethodInfoMap := map[string]*MethodInfo{}
+	targetMethodIfBranchesMap := make(map[string][]*ifBranch, 0)
 	targetMethodDepMethodsMap := make(map[string][]IFDepMethod, 0)
+	// コンテキストのキャンセルを確認している最初の位置
+	targetMethodCancelPositionMap := make(map[string]token.Pos, 0)
+	// テスト対象の関数の定義の位置
+	targetMethodPositionMap := make(map[string]token.Pos, 0)
+	// HTTPハンドラーのテスト対象の関数の、正常系で期待するステータスコード
+	targetMethodSuccessStatusMap := make(map[string]string, 0)
+	// テスト対象の関数で呼び出している、実行ごとに結果が変わる関数(関数ごとに最初の呼び出しのみ)
+	targetMethodNondeterministicMap := make(map[string][]*NondeterministicCall, 0)
+	// テストケースの生成時に対象外とした構文と、実行ごとに結果が変わる関数の呼び出し
+	var skipped []*Diagnostic
 
 	nodeFilter := []ast.Node{
 		(*ast.FuncDecl)(nil),
 		(*ast.AssignStmt)(nil),
+		(*ast.ValueSpec)(nil),
 		(*ast.ReturnStmt)(nil),
 		(*ast.IfStmt)(nil),
+		(*ast.CallExpr)(nil),
+		(*ast.SwitchStmt)(nil),
+		(*ast.TypeSwitchStmt)(nil),
+		(*ast.SelectStmt)(nil),
 	}
 
 	methodName := ""
-	methodReturnNum := 0
+	// テスト対象の関数の終わりの位置(関数の外の呼び出しを除くため)
+	var methodEnd token.Pos
+	addDepMethod := func(depMethod IFDepMethod) {
+		targetMethodDepMethodsMap[methodName] = append(targetMethodDepMethodsMap[methodName], depMethod)
+		if mockMethod, ok := depMethod.(*MockMethod); ok && mockMethod.Field != "" {
+			if fieldInfo, ok := dest.FieldMap[mockMethod.Field]; ok && fieldInfo.IsNested {
+				skipped = append(skipped, newSkipDiagnostic(fset.Position(mockMethod.Position), "diagnostic.nested_mock", methodName, mockMethod.Field))
+			}
+		}
+	}
+	addNondeterministicCall := func(call *NondeterministicCall) {
+		for _, c := range targetMethodNondeterministicMap[methodName] {
+			if c.Func == call.Func {
+				return
+			}
+		}
+		targetMethodNondeterministicMap[methodName] = append(targetMethodNondeterministicMap[methodName], call)
+		skipped = append(skipped, newDiagnostic(call.Position, "diagnostic.nondeterministic", methodName, call.Func))
+	}
 	inspect.Preorder(nodeFilter, func(node ast.Node) {
 		switch n := node.(type) {
 		case *ast.FuncDecl:
 			var isSuccess bool
-			methodName, methodReturnNum, isSuccess = extractValuesFromFuncDecl(n, targetStructName)
+			methodName, isSuccess = extractValuesFromFuncDecl(n, targetStructName)
+			methodEnd = n.End()
+			resolver.reset(n)
 			if !isSuccess {
 				return
 			}
+			targetMethodPositionMap[methodName] = n.Pos()
+			if len(resolver.argFieldMap) != 0 {
+				targetMethodArgFieldMap[methodName] = resolver.argFieldMap
+			}
+			params, fuzzable := resolver.methodParams(n)
+			targetMethodInfoMap[methodName] = &MethodInfo{
+				ContextParam: resolver.contextParam,
+				Handler:      resolver.handler,
+				GRPC:         resolver.grpc,
+				Params:       params,
+				Fuzzable:     fuzzable,
+			}
+			if resolver.handler != "" {
+				targetMethodSuccessStatusMap[methodName] = resolver.successStatus(n)
+			}
 		case *ast.AssignStmt:
+			resolver.registerAssign(n.Lhs, n.Rhs)
 			callExpr, ok := n.Rhs[0].(*ast.CallExpr)
 			if !ok {
 				return
 			}
-			depMethod, isSuccess := extractDepMethodFromCallExpr(callExpr, abbreviationName, methodReturnNum, fieldMap)
+			depMethod, isSuccess := extractDepMethodFromCallExpr(callExpr, resolver)
 			if isSuccess {
-				targetMethodDepMethodsMap[methodName] = append(targetMethodDepMethodsMap[methodName], depMethod)
+				addDepMethod(depMethod)
+			}
+		case *ast.ValueSpec:
+			names := make([]ast.Expr, 0, len(n.Names))
+			for _, name := range n.Names {
+				names = append(names, name)
+			}
+			resolver.registerAssign(names, n.Values)
+			if len(n.Values) == 0 {
+				return
+			}
+			callExpr, ok := n.Values[0].(*ast.CallExpr)
+			if !ok {
+				return
+			}
+			depMethod, isSuccess := extractDepMethodFromCallExpr(callExpr, resolver)
+			if isSuccess {
+				addDepMethod(depMethod)
 			}
 		case *ast.ReturnStmt:
+			depMethodNum := 0
 			for _, result := range n.Results {
 				callExpr, ok := result.(*ast.CallExpr)
 				if !ok {
-					return
+					break
 				}
-				depMethod, isSuccess := extractDepMethodFromCallExpr(callExpr, abbreviationName, methodReturnNum, fieldMap)
+				depMethod, isSuccess := extractDepMethodFromCallExpr(callExpr, resolver)
 				if isSuccess {
-					targetMethodDepMethodsMap[methodName] = append(targetMethodDepMethodsMap[methodName], depMethod)
+					addDepMethod(depMethod)
+					depMethodNum++
 				}
 			}
+			if depMethodNum > 1 {
+				skipped = append(skipped, newSkipDiagnostic(fset.Position(n.Pos()), "diagnostic.multi_call_return", methodName))
+			}
 		case *ast.IfStmt:
-			callExpr, ok := n.Cond.(*ast.CallExpr)
+			cond := astutil.Unparen(n.Cond)
+			if unaryExpr, ok := cond.(*ast.UnaryExpr); ok && unaryExpr.Op == token.NOT {
+				cond = astutil.Unparen(unaryExpr.X)
+			}
+			callExpr, ok := cond.(*ast.CallExpr)
 			if ok {
-				depMethod, isSuccess := extractDepMethodFromCallExpr(callExpr, abbreviationName, methodReturnNum, fieldMap)
+				depMethod, isSuccess := extractDepMethodFromCallExpr(callExpr, resolver)
 				if isSuccess {
-					targetMethodDepMethodsMap[methodName] = append(targetMethodDepMethodsMap[methodName], depMethod)
+					addDepMethod(depMethod)
 				}
 			}
-			pos, isSuccess := extractPositionFromIfStmt(n)
+			_, isSuccess, skipCode := extractPositionFromIfStmt(n)
+			// mock化するメソッドが返したエラーの確認は、mockからエラーを返すテストケースにする
+			if !isSuccess && skipCode == "diagnostic.skipped_if_err" && resolver.checksMockErr(n) {
+				isSuccess = true
+			}
 			if !isSuccess {
+				if methodName != "" {
+					skipped = append(skipped, newSkipDiagnostic(fset.Position(n.Pos()), skipCode, methodName, types.ExprString(n.Cond)))
+				}
+				return
+			}
+			branch := resolver.newIfBranch(n)
+			if branch.notForced {
+				skipped = append(skipped, newDiagnostic(fset.Position(n.Pos()), "diagnostic.branch_not_forced", methodName, branch.condition))
+			}
+			targetMethodIfBranchesMap[methodName] = append(targetMethodIfBranchesMap[methodName], branch)
+		case *ast.CallExpr:
+			if methodName != "" && n.Pos() < methodEnd {
+				if call, ok := resolver.nondeterministicCall(n, fset); ok {
+					addNondeterministicCall(call)
+				}
+			}
+			if _, ok := targetMethodCancelPositionMap[methodName]; ok {
 				return
 			}
-			targetMethodIfPositionsMap[methodName] = append(targetMethodIfPositionsMap[methodName], pos)
+			if resolver.contextParam != "" && resolver.isContextCancelCheck(n) {
+				targetMethodCancelPositionMap[methodName] = n.Pos()
+			}
+		case *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+			if methodName != "" {
+				skipped = append(skipped, newSkipDiagnostic(fset.Position(n.Pos()), "diagnostic.skipped_switch", methodName))
+			}
+		}
+	})
+
+	// 依存しているメソッドを呼び出していなくても、コンテキストのキャンセルを確認している場合は、キャンセルのテストケースを生成する
+	for name := range targetMethodCancelPositionMap {
+		if _, ok := targetMethodPositionMap[name]; ok && targetMethodDepMethodsMap[name] == nil {
+			targetMethodDepMethodsMap[name] = []IFDepMethod{}
+		}
+	}
+	for name, pos := range targetMethodPositionMap {
+		if _, ok := targetMethodDepMethodsMap[name]; !ok {
+			skipped = append(skipped, newSkipDiagnostic(fset.Position(pos), "diagnostic.no_test_cases", name))
 		}
+	}
+	sort.Slice(skipped, func(i, j int) bool {
+		return skipped[i].Position.Offset < skipped[j].Position.Offset
 	})
+	dest.Diagnostics = append(dest.Diagnostics, skipped...)
 
 	for k, v := range targetMethodDepMethodsMap {
-		targetMethodTestCaseMap[k] = getTestCases(fset, targetMethodIfPositionsMap[k], v)
+		targetMethodTestCaseMap[k] = getTestCases(fset, targetMethodIfBranchesMap[k], v)
+		methodInfo, hasMethodInfo := targetMethodInfoMap[k]
+		successPattern := targetMethodTestCaseMap[k][len(targetMethodTestCaseMap[k])-1]
+		if status, ok := targetMethodSuccessStatusMap[k]; ok {
+			successPattern.WantStatus = status
+		}
+		if hasMethodInfo && methodInfo.GRPC != nil {
+			successPattern.WantCode = codeOK
+		}
+		cancelPos, ok := targetMethodCancelPositionMap[k]
+		if !ok || !hasMethodInfo || methodInfo.ContextParam == "" {
+			continue
+		}
+		methodInfo.ChecksContextCancel = true
+		// キャンセルを確認するif文の分岐がある場合は、そのテストケースをキャンセルのテストケースにする
+		if !hasCancelTestCase(targetMethodTestCaseMap[k]) {
+			targetMethodTestCaseMap[k] = insertCancelTestCase(fset, targetMethodTestCaseMap[k], cancelPos, v)
+		}
 	}
 
-	return targetMethodTestCaseMap
+	// 実行ごとに結果が変わる関数は、その行以降で分岐するテストケース(if文の条件式での呼び出しを含む)と正常系のテストケースに影響する
+	for k, calls := range targetMethodNondeterministicMap {
+		targetMethodInfoMap[k].NondeterministicCalls = calls
+		for _, testCase := range targetMethodTestCaseMap[k] {
+			for _, call := range calls {
+				if testCase.IsSuccessPattern || call.Position.Line <= testCase.Line {
+					testCase.Nondeterministic = append(testCase.Nondeterministic, call.Func)
+				}
+			}
+		}
+	}
+
+	dest.TargetMethodTesCasesMap = targetMethodTestCaseMap
+	dest.ArgFieldMap = targetMethodArgFieldMap
+	dest.MethodInfoMap = targetMethodInfoMap
+}
+
+// insertCancelTestCase コンテキストがキャンセルされた場合のテストケースを、正常系のテストケースの前に追加する
+// キャンセルを確認するまでに呼び出される依存しているメソッドのみを、テストケースに含める
+func insertCancelTestCase(fset *token.FileSet, testcases []*TestCase, cancelPos token.Pos, depMethods []IFDepMethod) []*TestCase {
+	cancelDepMethods := make([]IFDepMethod, 0, len(depMethods))
+	for _, depMethod := range depMethods {
+		if depMethod.GetPosition() < cancelPos {
+			cancelDepMethods = append(cancelDepMethods, depMethod)
+		}
+	}
+	cancelTestCase := &TestCase{
+		Line:            fset.Position(cancelPos).Line,
+		IsCancelPattern: true,
+		WantErr:         &WantErr{Kind: WantErrAny},
+		depMethods:      cancelDepMethods,
+	}
+	last := len(testcases) - 1
+	return append(testcases[:last:last], cancelTestCase, testcases[last])
+}
+
+// hasCancelTestCase コンテキストがキャンセルされた場合のテストケースがあるか否か
+func hasCancelTestCase(testcases []*TestCase) bool {
+	for _, testcase := range testcases {
+		if testcase.IsCancelPattern {
+			return true
+		}
+	}
+	return false
 }
 
 func extractRecvTypeName(src ast.Expr) (string, error) {
 	var name string
 	switch recvType := src.(type) {
 	case *ast.StarExpr:
+		return extractRecvTypeName(recvType.X)
+	case *ast.IndexExpr:
+		// 型パラメータを1つ持つ構造体(例: Service[T])
+		x, ok := recvType.X.(*ast.Ident)
+		if !ok {
+			return "", NewError("error.unexpected_format")
+		}
+		name = x.Name
+	case *ast.IndexListExpr:
+		// 型パラメータを複数持つ構造体(例: Service[K, V])
 		x, ok := recvType.X.(*ast.Ident)
 		if !ok {
-			return "", errors.New("想定していないデータ形式です")
+			return "", NewError("error.unexpected_format")
 		}
 		name = x.Name
 	case *ast.Ident:
 		name = recvType.Name
+	default:
+		return "", NewError("error.unexpected_format")
 	}
 	return name, nil
 }
 
-func extractValuesFromFuncDecl(src *ast.FuncDecl, targetStructName string) (methodName string, methodReturnNum int, isSuccess bool) {
-	if src.Recv == nil {
-		return
-	}
-	recvTypeName, err := extractRecvTypeName(src.Recv.List[0].Type)
-	if err != nil {
-		return
-	}
-	if recvTypeName != targetStructName {
-		return
+// extractValuesFromFuncDecl テスト対象のメソッドもしくは関数から、名前を抽出する
+func extractValuesFromFuncDecl(src *ast.FuncDecl, targetStructName string) (methodName string, isSuccess bool) {
+	if src.Recv != nil {
+		recvTypeName, err := extractRecvTypeName(src.Recv.List[0].Type)
+		if err != nil {
+			return
+		}
+		if recvTypeName != targetStructName {
+			return
+		}
 	}
 	// 値の抽出
-	if src.Type.Results != nil {
-		methodReturnNum = len(src.Type.Results.List)
-	}
 	methodName = src.Name.Name
 	isSuccess = true
 	return
 }
 
-func extractPositionFromIfStmt(src *ast.IfStmt) (pos token.Pos, isSuccess bool) {
+// extractPositionFromIfStmt テストケースの分岐点とするif文の位置を抽出する
+// 対象外のif文の場合は、その理由を表すメッセージのキーを返す
+func extractPositionFromIfStmt(src *ast.IfStmt) (pos token.Pos, isSuccess bool, skipCode string) {
 	var ok bool
 	var binaryExpr *ast.BinaryExpr
 	binaryExpr, ok = src.Cond.(*ast.BinaryExpr)
 	if !ok {
-		return src.Pos(), true
+		return src.Pos(), true, ""
 	}
 
 	bodyLen := len(src.Body.List)
 	if bodyLen == 1 {
 		_, ok = src.Body.List[0].(*ast.ReturnStmt)
 		if !ok {
-			return 0, false
+			return 0, false, "diagnostic.skipped_if_no_return"
 		}
 	}
 
 	var x *ast.Ident
 	x, ok = binaryExpr.X.(*ast.Ident)
 	if !ok {
-		return src.Pos(), true
+		return src.Pos(), true, ""
 	}
 	var y *ast.Ident
 	y, ok = binaryExpr.Y.(*ast.Ident)
 	if !ok {
-		return src.Pos(), true
+		return src.Pos(), true, ""
 	}
 
 	if strings.Contains(strings.ToLower(x.String()), "err") &&
 		binaryExpr.Op.String() == "!=" &&
 		y.String() == "nil" {
-		return 0, false
+		return 0, false, "diagnostic.skipped_if_err"
 	}
-	return src.Pos(), true
+	return src.Pos(), true, ""
 }
 
-func getTestCases(fset *token.FileSet, ifPositions []token.Pos, depMethods []IFDepMethod) []*TestCase {
-	if len(ifPositions) == 0 {
+func getTestCases(fset *token.FileSet, ifBranches []*ifBranch, depMethods []IFDepMethod) []*TestCase {
+	if len(ifBranches) == 0 {
 		return []*TestCase{{
 			Line:             0,
 			IsSuccessPattern: true,
+			WantErr:          &WantErr{Kind: WantErrNone},
 			depMethods:       depMethods,
 		}}
 	}
 
-	testcases := make([]*TestCase, 0, len(ifPositions)+1)
+	testcases := make([]*TestCase, 0, len(ifBranches)+1)
+	// 前のif文の分岐に入らないために、mock化するメソッドが返す値
+	var avoid mockReturns
 	index := 0
 	for i, depMethod := range depMethods {
-		if len(ifPositions) == index {
+		if len(ifBranches) == index {
 			// 最後のif文の失敗のテストケースの作成後
 			return append(testcases, &TestCase{
-				Line:             fset.Position(ifPositions[index-1]).Line,
+				Line:             fset.Position(ifBranches[index-1].pos).Line,
 				IsSuccessPattern: true,
+				WantErr:          &WantErr{Kind: WantErrNone},
+				mockReturns:      avoid,
 				depMethods:       depMethods,
 			})
 		}
-		ifLine := fset.Position(ifPositions[index]).Line
+		// 依存しているメソッドより前にある全てのif文は、そのメソッドを呼び出す前に分岐する
 		depMethodLine := fset.Position(depMethod.GetPosition()).Line
-		if ifLine < depMethodLine {
-			testcases = append(testcases, &TestCase{
-				Line:       fset.Position(ifPositions[index]).Line,
-				depMethods: depMethods[:i],
-			})
+		for index < len(ifBranches) && fset.Position(ifBranches[index].pos).Line < depMethodLine {
+			testcases = append(testcases, newBranchTestCase(fset, ifBranches[index], avoid, depMethods[:i]))
+			avoid = mergeMockReturns(avoid, ifBranches[index].avoid)
 			index++
 		}
 	}
 
 	// if文が全ての依存しているメソッドよりもまだ後の行にある場合
-	for ; index <= len(ifPositions); index++ {
-		if len(ifPositions) == index {
+	for ; index <= len(ifBranches); index++ {
+		if len(ifBranches) == index {
 			return append(testcases, &TestCase{
-				Line:             fset.Position(ifPositions[index-1]).Line,
+				Line:             fset.Position(ifBranches[index-1].pos).Line,
 				IsSuccessPattern: true,
+				WantErr:          &WantErr{Kind: WantErrNone},
+				mockReturns:      avoid,
 				depMethods:       depMethods,
 			})
 		}
-		testcases = append(testcases, &TestCase{
-			Line:       fset.Position(ifPositions[index]).Line,
-			depMethods: depMethods,
-		})
+		testcases = append(testcases, newBranchTestCase(fset, ifBranches[index], avoid, depMethods))
+		avoid = mergeMockReturns(avoid, ifBranches[index].avoid)
 	}
 
 	return testcases
 }
 
-func extractDepMethodFromCallExpr(src *ast.CallExpr, targetAbbreviationName string, methodReturnLen int, fieldMap map[string]*FieldInfo) (IFDepMethod, bool) {
-	targetMethod, isSuccess := extractTargetMethodFromCallExpr(src, targetAbbreviationName)
-	if isSuccess {
-		return targetMethod, true
-	}
-
-	mockMethod, isSuccess := extractMockMethodFromCallExpr(src, targetAbbreviationName, fieldMap)
-	if isSuccess {
-		mockMethod.ReturnLen = methodReturnLen
-		return mockMethod, true
-	}
-	return nil, false
-}
-
-func extractTargetMethodFromCallExpr(src *ast.CallExpr, targetAbbreviationName string) (*TargetMethod, bool) {
-	selectorExpr, ok := src.Fun.(*ast.SelectorExpr)
-	if !ok {
-		return nil, false
-	}
-	ident, ok := selectorExpr.X.(*ast.Ident)
-	if !ok {
-		return nil, false
+// newBranchTestCase if文の分岐に入る場合のテストケースを作成する
+// avoid: 前のif文の分岐に入らないために、mock化するメソッドが返す値
+func newBranchTestCase(fset *token.FileSet, src *ifBranch, avoid mockReturns, depMethods []IFDepMethod) *TestCase {
+	return &TestCase{
+		Line:       fset.Position(src.pos).Line,
+		Condition:  src.condition,
+		nameKey:    src.nameKey,
+		nameArgs:   src.nameArgs,
+		WantErr:    src.wantErr,
+		WantStatus: src.status,
+		WantCode:   src.code,
+		// コンテキストのキャンセルを確認する分岐は、キャンセルしたコンテキストを渡すテストケースにする
+		IsCancelPattern: src.checksCancel,
+		NotForced:       src.notForced,
+		mockReturns:     mergeMockReturns(avoid, src.take),
+		depMethods:      depMethods,
 	}
-	if targetAbbreviationName != ident.Name {
-		return nil, false
-	}
-	return &TargetMethod{
-		Name:     selectorExpr.Sel.Name,
-		Position: src.Pos(),
-	}, true
 }
 
-func extractMockMethodFromCallExpr(src *ast.CallExpr, targetAbbreviationName string, fieldMap map[string]*FieldInfo) (*MockMethod, bool) {
-	selectorExpr, ok := src.Fun.(*ast.SelectorExpr)
-	if !ok {
+// extractDepMethodFromCallExpr 呼び出し式から、依存しているメソッドを抽出する
+// ローカル変数への代入・埋め込まれたフィールド・ネストしたフィールド・メソッド値を経由した呼び出しも型情報から解決する
+func extractDepMethodFromCallExpr(src *ast.CallExpr, resolver *depResolver) (IFDepMethod, bool) {
+	depMethod, isSuccess := resolver.resolve(src)
+	if !isSuccess {
 		return nil, false
 	}
-	selectorExprForField, ok := selectorExpr.X.(*ast.SelectorExpr)
-	if !ok {
-		return nil, false
-	}
-	ident, ok := selectorExprForField.X.(*ast.Ident)
-	if !ok {
-		return nil, false
-	}
-	if targetAbbreviationName != ident.Name {
-		return nil, false
-	}
-	fieldInfo, ok := fieldMap[selectorExprForField.Sel.Name]
-	if !ok {
-		return nil, false
-	}
-	if !fieldInfo.IsInterface {
-		return nil, false
+	if mockMethod, ok := depMethod.(*MockMethod); ok {
+		mockMethod.ArgValues = resolver.argValues(src)
 	}
-	return &MockMethod{
-		Field:    selectorExprForField.Sel.Name,
-		Name:     selectorExpr.Sel.Name,
-		Position: src.Pos(),
-		ArgLen:   len(src.Args),
-	}, true
+	return depMethod, true
 }
@@ -0,0 +1,415 @@
+package internal
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// depResolver 型情報を使って、呼び出し式がどのメソッドに解決されるかを判定する
+type depResolver struct {
+	// 型チェック済みの情報
+	info *types.Info
+	// テスト対象のパッケージ
+	pkg *types.Package
+	// テスト対象のメソッドのレシーバー
+	recv types.Object
+	// テスト対象のメソッドを持つ構造体のフィールド情報
+	fieldMap map[string]*FieldInfo
+	// テスト対象の関数のmock化する引数と、その引数名
+	params map[types.Object]string
+	// テスト対象の関数のmock化する引数の情報
+	argFieldMap map[string]*FieldInfo
+	// テスト対象の関数の第一引数がcontext.Contextの場合の引数名
+	contextParam string
+	// テスト対象の関数がHTTPハンドラーの場合の種類(HandlerNetHTTP, HandlerEcho, HandlerGin)
+	handler string
+	// テスト対象のメソッドがgRPCのサービスのメソッドの場合の情報
+	grpc *GRPCInfo
+	// フィールドや引数を代入したローカル変数と、その参照先(例: repo := s.Repo)
+	aliases map[types.Object]*depRef
+	// メソッド値を代入したローカル変数と、そのメソッド(例: get := s.Repo.Get)
+	methodValues map[types.Object]*MockMethod
+	// 呼び出し式の戻り値のerrorを代入したローカル変数と、その呼び出し先の名前(例: err := s.Repo.Get() の場合はGet)
+	errSources map[types.Object]string
+	// mock化するメソッドの戻り値を代入したローカル変数と、その呼び出し式の位置と何番目の戻り値か(例: v, err := s.Repo.Get())
+	mockResults map[types.Object]*mockResult
+}
+
+// mockResult mock化するメソッドの戻り値
+type mockResult struct {
+	// mock化するメソッドの呼び出し式の位置
+	call token.Pos
+	// 何番目の戻り値か
+	index int
+}
+
+// depRef レシーバーもしくは引数を起点とした参照先
+type depRef struct {
+	// 起点となるレシーバーもしくは引数
+	root types.Object
+	// 起点から辿ったフィールドのパス(起点自身の場合は空文字)
+	path string
+}
+
+func newDepResolver(info *types.Info, pkg *types.Package, fieldMap map[string]*FieldInfo) *depResolver {
+	return &depResolver{
+		info:         info,
+		pkg:          pkg,
+		fieldMap:     fieldMap,
+		params:       map[types.Object]string{},
+		argFieldMap:  map[string]*FieldInfo{},
+		aliases:      map[types.Object]*depRef{},
+		methodValues: map[types.Object]*MockMethod{},
+		errSources:   map[types.Object]string{},
+		mockResults:  map[types.Object]*mockResult{},
+	}
+}
+
+// reset テスト対象の関数が切り替わった時に、レシーバー・引数・ローカル変数の情報を初期化する
+func (r *depResolver) reset(src *ast.FuncDecl) {
+	r.recv = nil
+	r.params = map[types.Object]string{}
+	r.argFieldMap = map[string]*FieldInfo{}
+	r.contextParam = ""
+	r.handler = ""
+	r.grpc = nil
+	r.aliases = map[types.Object]*depRef{}
+	r.methodValues = map[types.Object]*MockMethod{}
+	r.errSources = map[types.Object]string{}
+	r.mockResults = map[types.Object]*mockResult{}
+	if r.info == nil {
+		return
+	}
+	if src.Recv != nil && len(src.Recv.List[0].Names) != 0 {
+		r.recv = r.info.Defs[src.Recv.List[0].Names[0]]
+	}
+	r.handler = r.handlerKind(src)
+	r.grpc = r.grpcInfo(src)
+	for i, param := range src.Type.Params.List {
+		if i == 0 && len(param.Names) != 0 && isContextType(r.info.TypeOf(param.Type)) {
+			r.contextParam = param.Names[0].Name
+		}
+		for _, name := range param.Names {
+			obj := r.info.Defs[name]
+			if obj == nil || !isMockableParam(obj.Type()) {
+				continue
+			}
+			r.params[obj] = name.Name
+			r.argFieldMap[name.Name] = createFieldInfo(obj.Type(), r.pkg)
+		}
+	}
+}
+
+// registerAssign フィールドやメソッド値をローカル変数に代入している場合、その変数を記録する
+// フィールドやメソッド値以外を再代入した変数(例: repo = other)は、記録から取り除く
+func (r *depResolver) registerAssign(lhs []ast.Expr, rhs []ast.Expr) {
+	r.registerCallResults(lhs, rhs)
+	if r.info == nil {
+		return
+	}
+	// 右辺を全て評価してから代入する(例: a, b = b, a)
+	mockMethods := make([]*MockMethod, len(lhs))
+	refs := make([]*depRef, len(lhs))
+	// 複数の戻り値を代入している場合(例: repo, err = newRepo())は、フィールドを参照していない
+	if len(lhs) == len(rhs) {
+		for i := range rhs {
+			if mockMethod, ok := r.resolveMethodValue(rhs[i]); ok {
+				mockMethods[i] = mockMethod
+				continue
+			}
+			if ref, ok := r.ref(rhs[i]); ok && (ref.path != "" || ref.root != r.recv) {
+				refs[i] = ref
+			}
+		}
+	}
+	for i, expr := range lhs {
+		ident, ok := expr.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		obj := r.info.Defs[ident]
+		if obj == nil {
+			obj = r.info.Uses[ident]
+		}
+		if obj == nil {
+			continue
+		}
+		delete(r.methodValues, obj)
+		delete(r.aliases, obj)
+		if mockMethods[i] != nil {
+			r.methodValues[obj] = mockMethods[i]
+		} else if refs[i] != nil {
+			r.aliases[obj] = refs[i]
+		}
+	}
+}
+
+// resolve 呼び出し式を、テスト対象のメソッドもしくはmock化するメソッドに解決する
+func (r *depResolver) resolve(src *ast.CallExpr) (IFDepMethod, bool) {
+	if r.info == nil {
+		return nil, false
+	}
+	switch fun := astutil.Unparen(src.Fun).(type) {
+	case *ast.Ident:
+		mockMethod, ok := r.methodValues[r.info.Uses[fun]]
+		if !ok {
+			return nil, false
+		}
+		result := &MockMethod{
+			Field:        mockMethod.Field,
+			Param:        mockMethod.Param,
+			Name:         mockMethod.Name,
+			Position:     src.Pos(),
+			ArgLen:       len(src.Args),
+			ReturnLen:    mockMethod.ReturnLen,
+			ReturnValues: mockMethod.ReturnValues,
+			SQLExpect:    mockMethod.SQLExpect,
+		}
+		if result.SQLExpect != "" {
+			result.SQL = r.sqlQuery(result.Name, src)
+			result.SQLRows = r.sqlDestRows(result.Name, src)
+		}
+		return result, true
+	case *ast.SelectorExpr:
+		if ident, ok := astutil.Unparen(fun.X).(*ast.Ident); ok && r.recv != nil && r.info.Uses[ident] == r.recv {
+			sel := r.info.Selections[fun]
+			if sel != nil && sel.Kind() == types.MethodVal && len(sel.Index()) == 1 {
+				return &TargetMethod{
+					Name:     fun.Sel.Name,
+					Position: src.Pos(),
+				}, true
+			}
+		}
+		if mockMethod, ok := r.resolveRowScan(fun); ok {
+			mockMethod.SQLRows = r.sqlRows(src.Args)
+			return mockMethod, true
+		}
+		mockMethod, ok := r.resolveMethodValue(fun)
+		if !ok {
+			return nil, false
+		}
+		mockMethod.Position = src.Pos()
+		mockMethod.ArgLen = len(src.Args)
+		if mockMethod.SQLExpect != "" {
+			mockMethod.SQL = r.sqlQuery(mockMethod.Name, src)
+			mockMethod.SQLRows = r.sqlDestRows(mockMethod.Name, src)
+		}
+		return mockMethod, true
+	}
+	return nil, false
+}
+
+// resolveMethodValue セレクタ式が、インタフェース型のフィールドもしくは引数のメソッドを指している場合にそのメソッドを返す
+func (r *depResolver) resolveMethodValue(src ast.Expr) (*MockMethod, bool) {
+	selectorExpr, ok := astutil.Unparen(src).(*ast.SelectorExpr)
+	if !ok {
+		return nil, false
+	}
+	sel := r.info.Selections[selectorExpr]
+	if sel == nil || sel.Kind() != types.MethodVal {
+		return nil, false
+	}
+	ref, ok := r.ref(selectorExpr.X)
+	if !ok {
+		return nil, false
+	}
+	returnValues := r.zeroResults(sel.Type())
+	if ref.root != r.recv {
+		// 引数はインタフェース型そのもののみをmock化の対象にする
+		name, ok := r.params[ref.root]
+		if !ok || ref.path != "" {
+			return nil, false
+		}
+		return &MockMethod{
+			Param:        name,
+			Name:         selectorExpr.Sel.Name,
+			ReturnLen:    len(returnValues),
+			ReturnValues: returnValues,
+		}, true
+	}
+	// 埋め込まれたフィールド経由で昇格したメソッドの場合は、埋め込まれたフィールドまで辿る
+	embeddedPath := fieldNames(sel.Recv(), sel.Index()[:len(sel.Index())-1])
+	// *sql.DB・*sqlx.DBのフィールドのメソッドはsqlmockで差し替える(*sqlx.DBに埋め込まれた*sql.DBのメソッドも含む)
+	for i := 0; i <= len(embeddedPath); i++ {
+		path := joinFieldPath(ref.path, embeddedPath[:i]...)
+		if fieldInfo, ok := r.fieldMap[path]; ok && fieldInfo.SQLDB != "" {
+			return sqlMockMethod(path, selectorExpr.Sel.Name, returnValues)
+		}
+	}
+	path := joinFieldPath(ref.path, embeddedPath...)
+	fieldInfo, ok := r.fieldMap[path]
+	if !ok || !fieldInfo.IsInterface {
+		return nil, false
+	}
+	return &MockMethod{
+		Field:        path,
+		Name:         selectorExpr.Sel.Name,
+		ReturnLen:    len(returnValues),
+		ReturnValues: returnValues,
+	}, true
+}
+
+// isErrorType error型そのものか否か
+func isErrorType(src types.Type) bool {
+	return types.Identical(src, types.Universe.Lookup("error").Type())
+}
+
+// ref 式がレシーバーもしくはmock化する引数(とそのフィールド)を指している場合に、その参照先を返す
+func (r *depResolver) ref(src ast.Expr) (*depRef, bool) {
+	switch expr := astutil.Unparen(src).(type) {
+	case *ast.Ident:
+		obj := r.info.Uses[expr]
+		if obj == nil {
+			return nil, false
+		}
+		if obj == r.recv {
+			return &depRef{root: obj}, true
+		}
+		if _, ok := r.params[obj]; ok {
+			return &depRef{root: obj}, true
+		}
+		ref, ok := r.aliases[obj]
+		return ref, ok
+	case *ast.StarExpr:
+		return r.ref(expr.X)
+	case *ast.SelectorExpr:
+		sel := r.info.Selections[expr]
+		if sel == nil || sel.Kind() != types.FieldVal {
+			return nil, false
+		}
+		ref, ok := r.ref(expr.X)
+		if !ok {
+			return nil, false
+		}
+		return &depRef{
+			root: ref.root,
+			path: joinFieldPath(ref.path, fieldNames(sel.Recv(), sel.Index())...),
+		}, true
+	}
+	return nil, false
+}
+
+// isContextCancelCheck 呼び出し式がコンテキストのキャンセルの確認(ctx.Err(), ctx.Done())か否か
+func (r *depResolver) isContextCancelCheck(src *ast.CallExpr) bool {
+	if r.info == nil {
+		return false
+	}
+	selectorExpr, ok := astutil.Unparen(src.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	if selectorExpr.Sel.Name != "Err" && selectorExpr.Sel.Name != "Done" {
+		return false
+	}
+	return isContextType(r.info.TypeOf(selectorExpr.X))
+}
+
+// anyArg mockで期待する引数のうち、どの値でも一致する引数の式
+const anyArg = "gomock.Any()"
+
+// argValues 呼び出し式の各引数について、mockで期待する引数の初期値の式を作成する
+// context.Contextはどの値でも一致するようにgomock.Any()、それ以外は引数の型のゼロ値(例: 0, "", nil)にする
+// 型パラメータなど、ゼロ値の式を決められない型の引数もgomock.Any()にする
+func (r *depResolver) argValues(src *ast.CallExpr) []string {
+	results := make([]string, 0, len(src.Args))
+	for _, arg := range src.Args {
+		var t types.Type
+		if r.info != nil {
+			t = r.info.TypeOf(arg)
+		}
+		// ginのハンドラーは*gin.Contextをcontext.Contextとして渡すことが多いため、合わせてgomock.Any()にする
+		if t == nil || isContextType(t) || isNamedType(t, "github.com/gin-gonic/gin", "*Context") {
+			results = append(results, anyArg)
+			continue
+		}
+		results = append(results, r.argZeroValue(t))
+	}
+	return results
+}
+
+// argZeroValue mockで期待する引数の、型のゼロ値の式
+// gomockは引数の型も比較するため、int・string・bool以外の基本型(例: int64, 名前付きの型)は型変換する
+func (r *depResolver) argZeroValue(t types.Type) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Kind() == types.Invalid || u.Info()&types.IsUntyped != 0:
+			return anyArg
+		case types.Identical(t, types.Typ[types.Bool]):
+			return "false"
+		case u.Info()&types.IsBoolean != 0:
+			return types.TypeString(t, packageQualifier(r.pkg)) + "(false)"
+		case u.Info()&types.IsString != 0:
+			return r.constValue(t, constant.MakeString(""))
+		case u.Info()&types.IsNumeric != 0:
+			return r.constValue(t, constant.MakeInt64(0))
+		}
+	case *types.Interface:
+		// 型パラメータは実体化した型が分からないため、どの値でも一致させる
+		if _, ok := t.(*types.TypeParam); ok {
+			return anyArg
+		}
+	}
+	return r.zeroValue(t)
+}
+
+// isContextType context.Contextか否か
+func isContextType(src types.Type) bool {
+	named, ok := src.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+	return named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
+}
+
+// isMockableParam mock化の対象とする引数の型か否か
+// メソッドを持たないインタフェースや、error・context.Context・io.Writer・HTTPハンドラーの引数(http.ResponseWriter, echo.Context)は対象外とする
+func isMockableParam(src types.Type) bool {
+	iface, ok := src.Underlying().(*types.Interface)
+	if !ok || iface.NumMethods() == 0 {
+		return false
+	}
+	if types.Identical(src, types.Universe.Lookup("error").Type()) {
+		return false
+	}
+	named, ok := src.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return true
+	}
+	switch named.Obj().Pkg().Path() + "." + named.Obj().Name() {
+	case "context.Context", "io.Writer", "net/http.ResponseWriter",
+		"github.com/labstack/echo/v4.Context", "github.com/labstack/echo.Context":
+		return false
+	}
+	return true
+}
+
+// fieldNames 型から、インデックスの並びで辿ったフィールド名の一覧を返す
+func fieldNames(src types.Type, indexes []int) []string {
+	names := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		if ptr, ok := src.Underlying().(*types.Pointer); ok {
+			src = ptr.Elem()
+		}
+		structType, ok := src.Underlying().(*types.Struct)
+		if !ok {
+			return names
+		}
+		field := structType.Field(index)
+		names = append(names, field.Name())
+		src = field.Type()
+	}
+	return names
+}
+
+func joinFieldPath(base string, names ...string) string {
+	if base != "" {
+		names = append([]string{base}, names...)
+	}
+	return strings.Join(names, ".")
+}
@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"testing"
+)
+
+// analyzeSource テスト対象のファイルのソースコードを型チェックし、解析結果を返す
+func analyzeSource(t *testing.T, src string, typeArgs map[string]string) (*TestFile, error) {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "sample.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types:      map[ast.Expr]types.TypeAndValue{},
+		Defs:       map[*ast.Ident]types.Object{},
+		Uses:       map[*ast.Ident]types.Object{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+		Implicits:  map[ast.Node]types.Object{},
+		Instances:  map[*ast.Ident]types.Instance{},
+	}
+	conf := &types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("sample", fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return GetAnalysisResult(f, fset, pkg, info, typeArgs)
+}
+
+// depMethodNames テストケースで呼び出している依存しているメソッドを、「フィールド(引数).メソッド名」の一覧にする
+func depMethodNames(src *TestCase) []string {
+	names := make([]string, 0, len(src.depMethods))
+	for _, depMethod := range src.depMethods {
+		switch method := depMethod.(type) {
+		case *MockMethod:
+			owner := method.Field
+			if method.Param != "" {
+				owner = method.Param
+			}
+			names = append(names, owner+"."+method.Name)
+		case *TargetMethod:
+			names = append(names, method.Name)
+		}
+	}
+	return names
+}
+
+const resolverSource = `package sample
+
+import "context"
+
+type Repository interface {
+	Find(ctx context.Context, id int) (string, error)
+	Save(ctx context.Context, id int, name string) error
+}
+
+type Base struct {
+	Repo Repository
+}
+
+type deps struct {
+	Cache Repository
+}
+
+type Service struct {
+	Base
+	Other Repository
+	deps  deps
+}
+
+func (s *Service) Alias(ctx context.Context, id int) error {
+	repo := s.Other
+	_, err := repo.Find(ctx, id)
+	return err
+}
+
+func (s *Service) MethodValue(ctx context.Context, id int) error {
+	find := s.Other.Find
+	_, err := find(ctx, id)
+	return err
+}
+
+func (s *Service) Embedded(ctx context.Context, id int) error {
+	_, err := s.Repo.Find(ctx, id)
+	return err
+}
+
+func (s *Service) Promoted(ctx context.Context, id int) error {
+	base := s.Base
+	return base.Repo.Save(ctx, id, "")
+}
+
+func (s *Service) Nested(ctx context.Context, id int) error {
+	_, err := s.deps.Cache.Find(ctx, id)
+	return err
+}
+
+func (s *Service) Reassigned(ctx context.Context, id int) error {
+	repo := s.Other
+	repo = s.Repo
+	_, err := repo.Find(ctx, id)
+	return err
+}
+
+func (s *Service) Overwritten(ctx context.Context, id int) error {
+	repo := s.Other
+	repo = newRepository()
+	_, err := repo.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.Other.Save(ctx, id, "")
+}
+
+func newRepository() Repository {
+	return nil
+}
+
+func (s *Service) Param(ctx context.Context, id int, repo Repository) error {
+	r := repo
+	_, err := r.Find(ctx, id)
+	return err
+}
+`
+
+func TestDepResolver(t *testing.T) {
+	result, err := analyzeSource(t, resolverSource, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// テスト対象のメソッドと、正常系のテストケースで呼び出している依存しているメソッド
+	tests := map[string][]string{
+		"Alias":       {"Other.Find"},
+		"MethodValue": {"Other.Find"},
+		"Embedded":    {"Base.Repo.Find"},
+		"Promoted":    {"Base.Repo.Save"},
+		"Nested":      {"deps.Cache.Find"},
+		"Reassigned":  {"Base.Repo.Find"},
+		// フィールド以外を再代入した変数のメソッドはmock化しない
+		"Overwritten": {"Other.Save"},
+		"Param":       {"repo.Find"},
+	}
+	for method, want := range tests {
+		testCases := result.TargetMethodTesCasesMap[method]
+		if len(testCases) == 0 {
+			t.Errorf("no test cases of %s", method)
+			continue
+		}
+		if got := depMethodNames(testCases[len(testCases)-1]); !reflect.DeepEqual(got, want) {
+			t.Errorf("depMethods of %s = %v, want %v", method, got, want)
+		}
+	}
+}
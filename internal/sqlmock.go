@@ -0,0 +1,269 @@
+package internal
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// SQLのハンドルの種類
+const (
+	// SQLDBStd *sql.DB
+	SQLDBStd = "sql"
+	// SQLDBSqlx *sqlx.DB
+	SQLDBSqlx = "sqlx"
+)
+
+// sqlmockで期待する呼び出しのメソッド
+const (
+	sqlExpectQuery   = "ExpectQuery"
+	sqlExpectExec    = "ExpectExec"
+	sqlExpectPrepare = "ExpectPrepare"
+	sqlExpectBegin   = "ExpectBegin"
+)
+
+// sqlMethod sqlmockで差し替える*sql.DB・*sqlx.DBのメソッド
+type sqlMethod struct {
+	// sqlmockで期待する呼び出しのメソッド
+	expect string
+	// SQLを渡す引数の位置(SQLを渡さない場合は-1)
+	queryIndex int
+}
+
+// sqlMethods *sql.DB・*sqlx.DBのメソッドと、sqlmockで期待する呼び出し
+// 名前付きのパラメータを使うメソッド(NamedExecなど)は、実行するSQLが書き換わるため対象外とする
+var sqlMethods = map[string]sqlMethod{
+	"Query":            {sqlExpectQuery, 0},
+	"QueryContext":     {sqlExpectQuery, 1},
+	"QueryRow":         {sqlExpectQuery, 0},
+	"QueryRowContext":  {sqlExpectQuery, 1},
+	"Exec":             {sqlExpectExec, 0},
+	"ExecContext":      {sqlExpectExec, 1},
+	"Prepare":          {sqlExpectPrepare, 0},
+	"PrepareContext":   {sqlExpectPrepare, 1},
+	"Begin":            {sqlExpectBegin, -1},
+	"BeginTx":          {sqlExpectBegin, -1},
+	"Queryx":           {sqlExpectQuery, 0},
+	"QueryxContext":    {sqlExpectQuery, 1},
+	"QueryRowx":        {sqlExpectQuery, 0},
+	"QueryRowxContext": {sqlExpectQuery, 1},
+	"Get":              {sqlExpectQuery, 1},
+	"GetContext":       {sqlExpectQuery, 2},
+	"Select":           {sqlExpectQuery, 1},
+	"SelectContext":    {sqlExpectQuery, 2},
+	"MustExec":         {sqlExpectExec, 0},
+	"MustExecContext":  {sqlExpectExec, 1},
+	"Preparex":         {sqlExpectPrepare, 0},
+	"PreparexContext":  {sqlExpectPrepare, 1},
+	"Beginx":           {sqlExpectBegin, -1},
+	"BeginTxx":         {sqlExpectBegin, -1},
+	"MustBegin":        {sqlExpectBegin, -1},
+}
+
+// sqlDBKind *sql.DBもしくは*sqlx.DBの場合に、その種類を返す(それ以外は空文字)
+func sqlDBKind(src types.Type) string {
+	switch {
+	case isNamedType(src, "database/sql", "*DB"):
+		return SQLDBStd
+	case isNamedType(src, "github.com/jmoiron/sqlx", "*DB"):
+		return SQLDBSqlx
+	}
+	return ""
+}
+
+// sqlMockMethod *sql.DB・*sqlx.DBのフィールドのメソッドを、sqlmockで差し替えるメソッドにする
+// sqlmockで差し替えないメソッド(例: Ping, Close)の場合はfalse
+func sqlMockMethod(field, name string, returnValues []string) (*MockMethod, bool) {
+	method, ok := sqlMethods[name]
+	if !ok {
+		return nil, false
+	}
+	return &MockMethod{
+		Field:        field,
+		Name:         name,
+		SQLExpect:    method.expect,
+		ReturnLen:    len(returnValues),
+		ReturnValues: returnValues,
+	}, true
+}
+
+// sqlQuery 呼び出し式でSQLを渡している引数から、sqlmockで期待するSQLの式を作成する
+// SQLが文字列の定数の場合は、そのSQLだけに一致する式(例: regexp.QuoteMeta("SELECT ...")), それ以外はどのSQLにも一致する空文字の式にする
+func (r *depResolver) sqlQuery(name string, src *ast.CallExpr) string {
+	method := sqlMethods[name]
+	if method.queryIndex < 0 {
+		return ""
+	}
+	if method.queryIndex >= len(src.Args) {
+		return `""`
+	}
+	tv, ok := r.info.Types[src.Args[method.queryIndex]]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return `""`
+	}
+	return "regexp.QuoteMeta(" + strconv.Quote(strings.TrimSpace(constant.StringVal(tv.Value))) + ")"
+}
+
+// resolveRowScan *sql.Row・*sqlx.RowのScan(例: s.DB.QueryRowContext(ctx, query, id).Scan(&name))を、
+// 行を取得したメソッドに解決する(Scanが返すエラーを、sqlmockで期待する呼び出しが返すエラーとして扱う)
+func (r *depResolver) resolveRowScan(src *ast.SelectorExpr) (*MockMethod, bool) {
+	if src.Sel.Name != "Scan" && src.Sel.Name != "StructScan" {
+		return nil, false
+	}
+	call, ok := src.X.(*ast.CallExpr)
+	if !ok {
+		return nil, false
+	}
+	depMethod, ok := r.resolve(call)
+	if !ok {
+		return nil, false
+	}
+	mockMethod, ok := depMethod.(*MockMethod)
+	if !ok || mockMethod.SQLExpect != sqlExpectQuery {
+		return nil, false
+	}
+	// Scanの戻り値はerrorのみ
+	mockMethod.ReturnLen = 1
+	mockMethod.ReturnValues = []string{"nil"}
+	return mockMethod, true
+}
+
+// sqlDestRows Get・GetContextの場合に、読み込む先の引数から正常系でsqlmockが返す行の式を作成する
+// それ以外のメソッドは空文字(Select・Queryなどは行を返さなくてもエラーにならない)
+func (r *depResolver) sqlDestRows(name string, src *ast.CallExpr) string {
+	if name != "Get" && name != "GetContext" {
+		return ""
+	}
+	index := sqlMethods[name].queryIndex - 1
+	if index >= len(src.Args) {
+		return ""
+	}
+	return r.sqlRows(src.Args[index : index+1])
+}
+
+// sqlRows 行を読み込む先(Scanの引数など)から、正常系でsqlmockが返す行の式を作成する(例: sqlmock.NewRows([]string{"name"}).AddRow(""))
+// 読み込む先ごとに1つの列にする。読み込む先が構造体1つの場合は、sqlxと同じくフィールドごとの列(dbタグもしくは小文字にしたフィールド名)にする
+func (r *depResolver) sqlRows(dests []ast.Expr) string {
+	if len(dests) == 0 || r.info == nil {
+		return ""
+	}
+	var columns, values []string
+	if fields, ok := r.sqlStructFields(dests); ok {
+		for _, field := range fields {
+			columns = append(columns, strconv.Quote(field.column))
+			values = append(values, r.sqlRowValue(field.typ))
+		}
+	} else {
+		for i, dest := range dests {
+			columns = append(columns, strconv.Quote(sqlColumnName(dest, i)))
+			values = append(values, r.sqlRowValue(derefType(r.info.TypeOf(dest))))
+		}
+	}
+	return "sqlmock.NewRows([]string{" + strings.Join(columns, ", ") + "}).AddRow(" + strings.Join(values, ", ") + ")"
+}
+
+// sqlStructField 構造体のフィールドに対応する列
+type sqlStructField struct {
+	column string
+	typ    types.Type
+}
+
+// sqlStructFields 読み込む先が構造体へのポインタ1つの場合に、各フィールドに対応する列を返す
+// sql.Scannerを実装している構造体(例: sql.NullString)とtime.Timeは、1つの列として扱うためfalse
+func (r *depResolver) sqlStructFields(dests []ast.Expr) ([]sqlStructField, bool) {
+	if len(dests) != 1 {
+		return nil, false
+	}
+	ptr, ok := r.info.TypeOf(dests[0]).(*types.Pointer)
+	if !ok {
+		return nil, false
+	}
+	st, ok := ptr.Elem().Underlying().(*types.Struct)
+	if !ok || isNamedType(ptr.Elem(), "time", "Time") {
+		return nil, false
+	}
+	if obj, _, _ := types.LookupFieldOrMethod(ptr, true, nil, "Scan"); obj != nil {
+		return nil, false
+	}
+	fields := make([]sqlStructField, 0, st.NumFields())
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if !field.Exported() || field.Embedded() {
+			continue
+		}
+		column, _, _ := strings.Cut(reflect.StructTag(st.Tag(i)).Get("db"), ",")
+		if column == "-" {
+			continue
+		}
+		if column == "" {
+			column = strings.ToLower(field.Name())
+		}
+		fields = append(fields, sqlStructField{column: column, typ: field.Type()})
+	}
+	return fields, len(fields) != 0
+}
+
+// sqlColumnName 読み込む先の式から列名を作成する(例: &name・&u.Name: name・Name, それ以外はcol1のような連番)
+func sqlColumnName(src ast.Expr, index int) string {
+	if unary, ok := astutil.Unparen(src).(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		src = unary.X
+	}
+	switch expr := astutil.Unparen(src).(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.SelectorExpr:
+		return expr.Sel.Name
+	}
+	return "col" + strconv.Itoa(index+1)
+}
+
+// sqlRowValue 行の値の式を、読み込む先の型のゼロ値にする
+// 真偽値・文字列・数値とtime.Time以外(ポインタ・[]byte・sql.NullStringなど)は、NULLを読み込めるためnilにする
+func (r *depResolver) sqlRowValue(src types.Type) string {
+	if src == nil {
+		return "nil"
+	}
+	if _, ok := src.Underlying().(*types.Basic); ok || isNamedType(src, "time", "Time") {
+		return r.zeroValue(src)
+	}
+	return "nil"
+}
+
+// derefType ポインタ型の場合は、指している先の型を返す
+func derefType(src types.Type) types.Type {
+	if ptr, ok := src.(*types.Pointer); ok {
+		return ptr.Elem()
+	}
+	return src
+}
+
+// sqlErrNoRows 行がないことを表すエラー(Scan・Getは、sqlmockが空の行を返した場合にこのエラーを返す)
+const sqlErrNoRows = "sql.ErrNoRows"
+
+// createSQLReturnString sqlmockで期待する呼び出しが返す値の式を作成する
+// テストケースの分岐に入るためにエラーを返す必要がある場合は、そのエラーを返す(sql.ErrNoRowsの場合は空の行を返す)
+// それ以外の場合は、正常系で返す行や結果を返す
+func createSQLReturnString(src *MockMethod, values map[int]string) string {
+	if value, ok := values[src.ReturnLen-1]; ok && value != "nil" {
+		if value == sqlErrNoRows && src.SQLExpect == sqlExpectQuery {
+			return ".WillReturnRows(sqlmock.NewRows(nil))"
+		}
+		return ".WillReturnError(" + value + ")"
+	}
+	switch src.SQLExpect {
+	case sqlExpectQuery:
+		if src.SQLRows != "" {
+			return ".WillReturnRows(" + src.SQLRows + ")"
+		}
+		return ".WillReturnRows(sqlmock.NewRows(nil))"
+	case sqlExpectExec:
+		return ".WillReturnResult(sqlmock.NewResult(0, 0))"
+	}
+	return ""
+}
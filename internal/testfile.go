@@ -0,0 +1,282 @@
+package internal
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TextEdit テストファイルへの変更(位置はバイト単位のオフセット)
+type TextEdit struct {
+	Start   int
+	End     int
+	NewText string
+}
+
+// MergeMode 生成したテスト関数が既存のテストファイルにある場合の反映方法
+type MergeMode int
+
+const (
+	// MergeReplaceFunc 既存のテスト関数を、生成したテスト関数で置き換える
+	MergeReplaceFunc MergeMode = iota
+	// MergeAddCases 既存のテスト関数は利用者が編集している前提で、名前が一致するテストケースがないもののみを追加する
+	MergeAddCases
+)
+
+// MergeSummary 既存のテストファイルに反映した内容
+type MergeSummary struct {
+	// 追加したテスト関数名
+	AddedFuncs []string
+	// 置き換えたテスト関数名
+	ReplacedFuncs []string
+	// テスト関数名ごとの、追加したテストケース名
+	AddedCases map[string][]string
+}
+
+// MergeTestFile 生成したテストファイルの内容を、既存のテストファイルに反映する変更を作成する
+// 既存のテストファイルにないテスト関数は末尾に、importは最後のimport宣言に追加する
+// lang: MergeAddCasesで追加したテストケースに付けるコメントの言語
+func MergeTestFile(existing, generated []byte, mode MergeMode, lang string) ([]TextEdit, *MergeSummary, error) {
+	genFset := token.NewFileSet()
+	genFile, err := parser.ParseFile(genFset, "generated_test.go", generated, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "existing_test.go", existing, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+	existingFuncs := map[string]*ast.FuncDecl{}
+	for _, decl := range f.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Recv == nil {
+			existingFuncs[funcDecl.Name.Name] = funcDecl
+		}
+	}
+
+	summary := &MergeSummary{AddedCases: map[string][]string{}}
+	edits := make([]TextEdit, 0)
+	if importEdit, ok := createImportEdit(fset, f, genFset, genFile, generated); ok {
+		edits = append(edits, importEdit)
+	}
+	var appended strings.Builder
+	for _, decl := range genFile.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		existingFunc, ok := existingFuncs[funcDecl.Name.Name]
+		if !ok {
+			appended.WriteString("\n" + string(generated[funcStart(genFset, funcDecl):offset(genFset, funcDecl.End())]) + "\n")
+			summary.AddedFuncs = append(summary.AddedFuncs, funcDecl.Name.Name)
+			continue
+		}
+		switch mode {
+		case MergeReplaceFunc:
+			edits = append(edits, TextEdit{
+				Start:   funcStart(fset, existingFunc),
+				End:     offset(fset, existingFunc.End()),
+				NewText: string(generated[funcStart(genFset, funcDecl):offset(genFset, funcDecl.End())]),
+			})
+			summary.ReplacedFuncs = append(summary.ReplacedFuncs, funcDecl.Name.Name)
+		case MergeAddCases:
+			edit, added, ok := createAddCasesEdit(fset, existingFunc, existing, genFset, funcDecl, generated, lang)
+			if ok {
+				edits = append(edits, edit)
+				summary.AddedCases[funcDecl.Name.Name] = added
+			}
+		}
+	}
+	if appended.Len() != 0 {
+		text := appended.String()
+		if len(existing) != 0 && existing[len(existing)-1] != '\n' {
+			text = "\n" + text
+		}
+		edits = append(edits, TextEdit{Start: len(existing), End: len(existing), NewText: text})
+	}
+	return edits, summary, nil
+}
+
+// ApplyTextEdits 変更を内容に適用する(変更の範囲は重ならない前提)
+func ApplyTextEdits(content []byte, edits []TextEdit) []byte {
+	sorted := append([]TextEdit(nil), edits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start > sorted[j].Start
+	})
+	result := append([]byte(nil), content...)
+	for _, edit := range sorted {
+		result = append(result[:edit.Start], append([]byte(edit.NewText), result[edit.End:]...)...)
+	}
+	return result
+}
+
+// TestCaseNames テスト関数のテストケース(tests)の名前(name: "..."の値)の一覧
+func TestCaseNames(src *ast.FuncDecl) []string {
+	testsLit := findTestsLit(src)
+	if testsLit == nil {
+		return nil
+	}
+	names := make([]string, 0, len(testsLit.Elts))
+	for _, elt := range testsLit.Elts {
+		if name, ok := testCaseName(elt); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// createAddCasesEdit 既存のテスト関数のテストケースに、名前が一致するものがない生成したテストケースを追加する変更を作成する
+// 追加するテストケースには、追加したことを表すTODOコメントを付ける
+func createAddCasesEdit(fset *token.FileSet, existingFunc *ast.FuncDecl, existing []byte, genFset *token.FileSet, genFunc *ast.FuncDecl, generated []byte, lang string) (TextEdit, []string, bool) {
+	existingLit, genLit := findTestsLit(existingFunc), findTestsLit(genFunc)
+	if existingLit == nil || genLit == nil {
+		return TextEdit{}, nil, false
+	}
+	existingNames := map[string]bool{}
+	for _, name := range TestCaseNames(existingFunc) {
+		existingNames[name] = true
+	}
+	var text strings.Builder
+	added := make([]string, 0)
+	for _, elt := range genLit.Elts {
+		name, ok := testCaseName(elt)
+		if !ok || existingNames[name] {
+			continue
+		}
+		start := offset(genFset, elt.Pos())
+		indent := lineIndent(generated, start)
+		text.WriteString(indent + "// " + Localize(lang, "testcase.added_todo") + "\n")
+		text.WriteString(indent + string(generated[start:offset(genFset, elt.End())]) + ",\n")
+		added = append(added, name)
+	}
+	if len(added) == 0 {
+		return TextEdit{}, nil, false
+	}
+	// 閉じ括弧の行の先頭に追加する(閉じ括弧が開き括弧と同じ行にある場合は、改行して追加する)
+	rbrace := offset(fset, existingLit.Rbrace)
+	insertAt := lineStart(existing, rbrace)
+	newText := text.String()
+	if insertAt <= offset(fset, existingLit.Lbrace) {
+		insertAt, newText = rbrace, "\n"+newText
+	}
+	return TextEdit{Start: insertAt, End: insertAt, NewText: newText}, added, true
+}
+
+// findTestsLit テスト関数の、テストケースの一覧(tests := []struct{...}{...})の複合リテラルを探す
+func findTestsLit(src *ast.FuncDecl) *ast.CompositeLit {
+	if src.Body == nil {
+		return nil
+	}
+	var found *ast.CompositeLit
+	ast.Inspect(src.Body, func(node ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		assignStmt, ok := node.(*ast.AssignStmt)
+		if !ok || len(assignStmt.Lhs) != 1 || len(assignStmt.Rhs) != 1 {
+			return true
+		}
+		if ident, ok := assignStmt.Lhs[0].(*ast.Ident); !ok || ident.Name != "tests" {
+			return true
+		}
+		if lit, ok := assignStmt.Rhs[0].(*ast.CompositeLit); ok {
+			found = lit
+		}
+		return false
+	})
+	return found
+}
+
+// testCaseName テストケースの複合リテラルから、name: "..."の値を取り出す
+func testCaseName(src ast.Expr) (string, bool) {
+	lit, ok := src.(*ast.CompositeLit)
+	if !ok {
+		return "", false
+	}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		if key, ok := kv.Key.(*ast.Ident); !ok || key.Name != "name" {
+			continue
+		}
+		value, ok := kv.Value.(*ast.BasicLit)
+		if !ok || value.Kind != token.STRING {
+			return "", false
+		}
+		name, err := strconv.Unquote(value.Value)
+		return name, err == nil
+	}
+	return "", false
+}
+
+// createImportEdit 生成したテストファイルのimportのうち、既存のテストファイルにないものを追加する変更を作成する
+func createImportEdit(fset *token.FileSet, f *ast.File, genFset *token.FileSet, genFile *ast.File, generated []byte) (TextEdit, bool) {
+	importedPaths := map[string]bool{}
+	for _, spec := range f.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		importedPaths[path] = true
+	}
+	specs := make([]string, 0)
+	for _, spec := range genFile.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		if importedPaths[path] {
+			continue
+		}
+		specs = append(specs, string(generated[offset(genFset, spec.Pos()):offset(genFset, spec.End())]))
+	}
+	if len(specs) == 0 {
+		return TextEdit{}, false
+	}
+
+	var lastImport *ast.GenDecl
+	for _, decl := range f.Decls {
+		if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.IMPORT {
+			lastImport = genDecl
+		}
+	}
+	if lastImport != nil && lastImport.Rparen.IsValid() {
+		// import ( ... ) の閉じ括弧の前に追加する
+		rparen := offset(fset, lastImport.Rparen)
+		return TextEdit{Start: rparen, End: rparen, NewText: "\t" + strings.Join(specs, "\n\t") + "\n"}, true
+	}
+	insertAt := offset(fset, f.Name.End())
+	if lastImport != nil {
+		insertAt = offset(fset, lastImport.End())
+	}
+	return TextEdit{Start: insertAt, End: insertAt, NewText: "\n\nimport (\n\t" + strings.Join(specs, "\n\t") + "\n)"}, true
+}
+
+// funcStart 関数宣言の開始位置(ドキュメントコメントがある場合はその位置)
+func funcStart(fset *token.FileSet, src *ast.FuncDecl) int {
+	if src.Doc != nil {
+		return offset(fset, src.Doc.Pos())
+	}
+	return offset(fset, src.Pos())
+}
+
+func offset(fset *token.FileSet, pos token.Pos) int {
+	return fset.Position(pos).Offset
+}
+
+// lineStart 指定した位置を含む行の先頭の位置
+func lineStart(content []byte, pos int) int {
+	for pos > 0 && content[pos-1] != '\n' {
+		pos--
+	}
+	return pos
+}
+
+// lineIndent 指定した位置を含む行の、先頭から指定した位置までの空白
+func lineIndent(content []byte, pos int) string {
+	start := lineStart(content, pos)
+	indent := content[start:pos]
+	if strings.TrimSpace(string(indent)) != "" {
+		return ""
+	}
+	return string(indent)
+}
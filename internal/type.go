@@ -1,6 +1,10 @@
 package internal
 
-import "go/token"
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+)
 
 // TestFile テスト対象ファイルのASTから抽出した値を格納する構造体
 type TestFile struct {
@@ -8,16 +12,102 @@ type TestFile struct {
 	FieldMap map[string]*FieldInfo
 	// 各テスト対象のメソッドのテストケース一覧を管理
 	TargetMethodTesCasesMap map[string][]*TestCase
+	// 各テスト対象の関数におけるmock化する引数の情報を管理
+	ArgFieldMap map[string]map[string]*FieldInfo
+	// 各テスト対象の関数自体の情報を管理
+	MethodInfoMap map[string]*MethodInfo
+	// 型パラメータを持つテスト対象のメソッドや関数を、具体的な型で実体化した情報を管理
+	InstantiationMap map[string]*Instantiation
+	// 解析時に検出した、利用者に伝えるべき内容の一覧
+	Diagnostics []*Diagnostic
+}
+
+// MethodInfo テスト対象の関数自体の情報
+type MethodInfo struct {
+	// 第一引数がcontext.Contextの場合の引数名
+	ContextParam string
+	// コンテキストのキャンセルを確認しているか(ctx.Err(), ctx.Done())
+	ChecksContextCancel bool
+	// 引数の情報(レシーバーは含まない)
+	Params []*ParamInfo
+	// HTTPハンドラーの場合の種類(HandlerNetHTTP, HandlerEcho, HandlerGin, HTTPハンドラーではない場合は空文字)
+	Handler string
+	// gRPCのサービスのメソッドの場合の情報(gRPCのサービスのメソッドではない場合はnil)
+	GRPC *GRPCInfo
+	// fuzzテストを生成できるか(context.Contextとmock化する引数以外の引数が、全てfuzzテストで値を生成できる型か)
+	Fuzzable bool
+	// 呼び出している、実行ごとに結果が変わる関数(関数ごとに最初の呼び出しのみ)
+	NondeterministicCalls []*NondeterministicCall
+}
+
+// Instantiation 型パラメータを具体的な型で実体化したメソッドや関数の情報
+type Instantiation struct {
+	// 型パラメータ名と具体的な型
+	TypeArgs map[string]string
+	// 実体化したレシーバーの型(ポインタの*は含まない, 例: Cache[int, any])
+	RecvValue string
+	// レシーバーがポインタか否か
+	RecvIsStar bool
+	// テスト関数名(gotestsはレシーバーの型パラメータを含めた名前にしてしまうため)
+	TestName string
+	// 実体化した引数の型(キーはテンプレートにおける引数名)
+	Params map[string]string
+	// 実体化した戻り値の型(errorを除く)
+	Results []string
+	// 関数呼び出し時に指定する型引数(例: [int])
+	CallTypeArgs string
+	// 制約から型引数を選択できなかったか(テストを生成しない)
+	Unresolved bool
+	// 実体化したシグネチャ
+	sig *types.Signature
+}
+
+// Diagnostic 解析時に検出した、利用者に伝えるべき内容
+type Diagnostic struct {
+	// 検出した位置
+	Position token.Position
+	// 内容
+	Message string
+	// 内容を表すメッセージのキー(内容を機械的に判別する場合に利用する)
+	Code string
+	// 重要度(SeverityWarning, SeverityInfo)
+	Severity string
+	// メッセージの書式に渡す値
+	args []interface{}
+}
+
+// Diagnosticの重要度
+const (
+	// SeverityWarning 生成されるテストコードを利用者が修正する必要がある内容
+	SeverityWarning = "warning"
+	// SeverityInfo 仕様としてテストケースの生成の対象外とした構文
+	SeverityInfo = "info"
+)
+
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Position, d.Message)
 }
 
 // FieldInfo フィールド情報
 type FieldInfo struct {
 	// インタフェースか否か
 	IsInterface bool
+	// ネストした構造体のフィールドか否か(FieldMapのキーは「親.子」のパスになる)
+	IsNested bool
+	// 関数型か否か
+	IsFunc bool
+	// 関数型の場合に、テストケースで渡す関数リテラル
+	FuncStub string
+	// mock化できない具象型の依存(例: *http.Client)か否か
+	IsConcrete bool
+	// sqlmockで差し替えるSQLのハンドルの種類(SQLDBStd: *sql.DB, SQLDBSqlx: *sqlx.DB, それ以外は空文字)
+	SQLDB string
 	// パッケージ名
 	PackageName string
 	// 型名
 	TypeName string
+	// パッケージ名で修飾した型(例: repository.IFSampleRepository, func() time.Time)
+	Type string
 	// テンプレートのパラメータに用いる型名
 	UpperCamelCaseTypeName string
 }
@@ -26,12 +116,58 @@ type FieldInfo struct {
 type TestCase struct {
 	// テストケースの分岐点となるif文の行数
 	Line int
+	// テストケースの分岐点となるif文の条件式(例: len(name) != len(updateName))
+	Condition string
+	// 条件式から作成するテストケース名のメッセージのキーと、書式に渡す値(キーが空の場合は条件式をそのまま利用する)
+	nameKey  string
+	nameArgs []interface{}
 	// 正常系か
 	IsSuccessPattern bool
+	// コンテキストがキャンセルされた場合のテストケースか
+	IsCancelPattern bool
+	// テストケースで期待するエラー(テスト対象の関数がエラーを返す場合のみ利用する)
+	WantErr *WantErr
+	// テストケースで期待するステータスコードの式(テスト対象の関数がHTTPハンドラーの場合のみ利用する, 決められない場合は空文字)
+	WantStatus string
+	// テストケースで期待するgRPCのステータスコードの式(テスト対象の関数がgRPCのサービスのメソッドの場合のみ利用する, 決められない場合は空文字)
+	WantCode string
+	// テストケースの分岐までに呼び出している、実行ごとに結果が変わる関数(例: time.Now)
+	Nondeterministic []string
+	// 分岐に入るためにmock化するメソッドが返す値を、条件式から決められなかったか
+	NotForced bool
+	// テストケースの分岐に入るために、mock化するメソッドが返す値
+	mockReturns mockReturns
 	// 依存しているメソッド一覧(自身のメソッド or mock化するメソッド)
 	depMethods []IFDepMethod
 }
 
+// WantErr テストケースで期待するエラー
+type WantErr struct {
+	// 期待するエラーの種類(WantErrNone, WantErrAny, WantErrSentinel, WantErrMessage, WantErrMock)
+	Kind string
+	// 比較するエラーの式(WantErrSentinelとWantErrMockの場合はerrors.Isで比較するエラー, WantErrMessageの場合はエラーメッセージの文字列リテラル)
+	Value string
+	// WantErrMockの場合の、mock化するメソッドの戻り値のうちerrorの位置
+	index int
+}
+
+// WantErrの種類
+const (
+	// WantErrNone エラーを返さないことを期待する
+	WantErrNone = "none"
+	// WantErrAny 何らかのエラーを返すことを期待する
+	WantErrAny = "any"
+	// WantErrSentinel errors.Isで比較できるエラー(センチネルエラーなど)を返すことを期待する
+	WantErrSentinel = "sentinel"
+	// WantErrMessage エラーメッセージ(Value)が一致するエラーを返すことを期待する
+	WantErrMessage = "message"
+	// WantErrMock mock化するメソッドが返すテスト用のエラー(Value)を、そのまま返すかラップして返すことを期待する
+	WantErrMock = "mock"
+)
+
+// MockErrName テストケースでmock化するメソッドが返す、テスト用のエラーの変数名
+const MockErrName = "errMock"
+
 type IFDepMethod interface {
 	GetPosition() token.Pos
 }
@@ -40,14 +176,26 @@ type IFDepMethod interface {
 type MockMethod struct {
 	// メソッドを持つフィールド
 	Field string
+	// メソッドを持つ引数(引数のメソッドの場合のみ)
+	Param string
 	// メソッド名
 	Name string
 	// ASTにおける出現位置
 	Position token.Pos
 	// 引数の数
 	ArgLen int
+	// 各引数の期待する値の初期値の式(context.Contextはgomock.Any(), それ以外は型のゼロ値)
+	ArgValues []string
 	// 戻り値の数
 	ReturnLen int
+	// 各戻り値のゼロ値の式(例: "", 0, time.Time{}, nil)
+	ReturnValues []string
+	// sqlmockで期待する呼び出しのメソッド(例: ExpectQuery, *sql.DB・*sqlx.DBのフィールドのメソッドの場合のみ)
+	SQLExpect string
+	// sqlmockで期待するSQLの式(例: regexp.QuoteMeta("SELECT ..."))
+	SQL string
+	// 正常系でsqlmockが返す行の式(例: sqlmock.NewRows([]string{"name"}).AddRow(""), 行を読み込む先が分からない場合は空文字)
+	SQLRows string
 }
 
 func (m *MockMethod) GetPosition() token.Pos {
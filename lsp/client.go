@@ -0,0 +1,145 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// Client 同じプロセス内でサーバーを動かし、エディタの代わりにメッセージを送るクライアント
+// サーバーの動作をエディタなしで確認する(テストする)ために利用する
+// CallとNotifyは同時に呼び出さない想定
+type Client struct {
+	c *conn
+	// サーバーへの書き込み側(閉じるとサーバーの読み込みが終端に達する)
+	w io.Closer
+	// リクエストのID
+	nextID int
+
+	mu sync.Mutex
+	// レスポンスを待っているリクエストのIDと、レスポンスを受け取るチャネル
+	pending map[string]chan *message
+	// サーバーから受け取ったwindow/logMessageの通知
+	logMessages []*LogMessageParams
+	// サーバーからの読み込みが終了した理由
+	readErr error
+
+	// サーバーの終了を待つためのチャネル
+	done chan error
+}
+
+// NewClient サーバーをgoroutineで起動し、io.Pipeで接続したクライアントを返す
+func NewClient(ctx context.Context, s *Server) *Client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	cl := &Client{
+		c:       newConn(clientIn, clientOut),
+		w:       clientOut,
+		pending: map[string]chan *message{},
+		done:    make(chan error, 1),
+	}
+	go func() {
+		err := s.Serve(ctx, serverIn, serverOut)
+		serverOut.Close()
+		cl.done <- err
+	}()
+	// io.Pipeは読み込まれるまで書き込みが止まるため、サーバーからのメッセージは常に読み込む
+	go cl.readLoop()
+	return cl
+}
+
+// readLoop サーバーからのメッセージを読み込み、レスポンスは待っているリクエストに渡し、通知は記録する
+func (cl *Client) readLoop() {
+	for {
+		msg, err := cl.c.read()
+		cl.mu.Lock()
+		if err != nil {
+			cl.readErr = err
+			for id, ch := range cl.pending {
+				close(ch)
+				delete(cl.pending, id)
+			}
+			cl.mu.Unlock()
+			return
+		}
+		if msg.ID == nil {
+			cl.receiveNotification(msg)
+		} else if ch, ok := cl.pending[string(*msg.ID)]; ok {
+			ch <- msg
+			delete(cl.pending, string(*msg.ID))
+		}
+		cl.mu.Unlock()
+	}
+}
+
+// receiveNotification サーバーからの通知を記録する
+func (cl *Client) receiveNotification(msg *message) {
+	if msg.Method != "window/logMessage" {
+		return
+	}
+	params := new(LogMessageParams)
+	if err := json.Unmarshal(msg.Params, params); err == nil {
+		cl.logMessages = append(cl.logMessages, params)
+	}
+}
+
+// Call リクエストを送り、レスポンスのresultをresultに格納する
+// エラーのレスポンスの場合は*ResponseErrorを返す
+func (cl *Client) Call(method string, params, result interface{}) error {
+	cl.nextID++
+	id := json.RawMessage(strconv.Itoa(cl.nextID))
+	b, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	ch := make(chan *message, 1)
+	cl.mu.Lock()
+	if cl.readErr != nil {
+		cl.mu.Unlock()
+		return cl.readErr
+	}
+	cl.pending[string(id)] = ch
+	cl.mu.Unlock()
+	if err := cl.c.write(&message{ID: &id, Method: method, Params: b}); err != nil {
+		return err
+	}
+	msg, ok := <-ch
+	if !ok {
+		cl.mu.Lock()
+		defer cl.mu.Unlock()
+		return cl.readErr
+	}
+	if msg.Error != nil {
+		return msg.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(msg.Result, result)
+}
+
+// Notify 通知を送る
+func (cl *Client) Notify(method string, params interface{}) error {
+	return cl.c.notify(method, params)
+}
+
+// LogMessages サーバーから受け取ったwindow/logMessageの通知の一覧
+func (cl *Client) LogMessages() []*LogMessageParams {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return append([]*LogMessageParams(nil), cl.logMessages...)
+}
+
+// Close shutdownとexitを送り、サーバーの終了を待つ
+func (cl *Client) Close() error {
+	if err := cl.Call("shutdown", nil, nil); err != nil {
+		return err
+	}
+	if err := cl.Notify("exit", nil); err != nil {
+		return err
+	}
+	cl.w.Close()
+	return <-cl.done
+}
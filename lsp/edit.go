@@ -0,0 +1,105 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/kazdevl/tgen/internal"
+)
+
+// newFileEdit テストファイルを作成し、生成した内容を書き込む変更を作成する
+func newFileEdit(uri string, generated []byte) *WorkspaceEdit {
+	return &WorkspaceEdit{
+		DocumentChanges: []interface{}{
+			&CreateFile{Kind: "create", URI: uri, Options: &CreateFileOptions{IgnoreIfExists: true}},
+			&TextDocumentEdit{
+				TextDocument: OptionalVersionedTextDocumentIdentifier{URI: uri},
+				Edits:        []TextEdit{{NewText: string(generated)}},
+			},
+		},
+	}
+}
+
+// mergeTestFile 生成したテストファイルの内容を、既存のテストファイルに反映する変更を作成する
+// 同じ名前のテスト関数は置き換え、ない場合は末尾に追加する
+// 既存のテストファイルにないimportは、最後のimport宣言に追加する
+func mergeTestFile(existing, generated []byte) ([]TextEdit, error) {
+	edits, _, err := internal.MergeTestFile(existing, generated, internal.MergeReplaceFunc, "")
+	if err != nil {
+		return nil, err
+	}
+	textEdits := make([]TextEdit, 0, len(edits))
+	for _, edit := range edits {
+		textEdits = append(textEdits, TextEdit{
+			Range:   Range{Start: positionAt(existing, edit.Start), End: positionAt(existing, edit.End)},
+			NewText: edit.NewText,
+		})
+	}
+	return textEdits, nil
+}
+
+// positionAt バイト単位のオフセットを、行とUTF-16のコード単位で数えた文字の位置に変換する
+func positionAt(content []byte, offset int) Position {
+	var pos Position
+	for i := 0; i < offset && i < len(content); {
+		r, size := utf8.DecodeRune(content[i:])
+		i += size
+		if r == '\n' {
+			pos.Line++
+			pos.Character = 0
+			continue
+		}
+		pos.Character += utf16Len(r)
+	}
+	return pos
+}
+
+// offsetAt 行とUTF-16のコード単位で数えた文字の位置を、バイト単位のオフセットに変換する
+// 範囲外の位置は、行末もしくはドキュメントの末尾に丸める
+func offsetAt(content []byte, pos Position) int {
+	line, i := 0, 0
+	for line < pos.Line && i < len(content) {
+		if content[i] == '\n' {
+			line++
+		}
+		i++
+	}
+	for character := 0; character < pos.Character && i < len(content); {
+		r, size := utf8.DecodeRune(content[i:])
+		if r == '\n' {
+			break
+		}
+		character += utf16Len(r)
+		i += size
+	}
+	return i
+}
+
+// utf16Len 文字をUTF-16で表した場合のコード単位の数
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// uriToPath file://のURIをファイルパスに変換する
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", &ResponseError{Code: codeInvalidParams, Message: "unsupported uri: " + uri}
+	}
+	return filepath.FromSlash(u.Path), nil
+}
+
+// pathToURI ファイルパスをfile://のURIに変換する
+func pathToURI(path string) string {
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
@@ -0,0 +1,217 @@
+package lsp
+
+import "encoding/json"
+
+// Language Server Protocolで利用する型のうち、このサーバーが扱うもの
+// フィールド名はプロトコルで定められているため、jsonのタグで指定する
+
+// message JSON-RPCのリクエスト・通知・レスポンス
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *ResponseError   `json:"error,omitempty"`
+}
+
+// isNotification レスポンスを返さない通知か否か
+func (m *message) isNotification() bool {
+	return m.ID == nil
+}
+
+// ResponseError JSON-RPCのエラー
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ResponseError) Error() string {
+	return e.Message
+}
+
+// JSON-RPCのエラーコード
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// Position ドキュメント内の位置(文字はUTF-16のコード単位で数える)
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range ドキュメント内の範囲
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+// OptionalVersionedTextDocumentIdentifier バージョンがnullの場合は、ディスク上の内容を編集する
+type OptionalVersionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version *int   `json:"version"`
+}
+
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+type TextDocumentEdit struct {
+	TextDocument OptionalVersionedTextDocumentIdentifier `json:"textDocument"`
+	Edits        []TextEdit                              `json:"edits"`
+}
+
+// CreateFile ファイルの作成
+// WorkspaceEdit.DocumentChangesにTextDocumentEditと混在させるため、Kindには"create"を指定する
+type CreateFile struct {
+	Kind    string             `json:"kind"`
+	URI     string             `json:"uri"`
+	Options *CreateFileOptions `json:"options,omitempty"`
+}
+
+type CreateFileOptions struct {
+	Overwrite      bool `json:"overwrite,omitempty"`
+	IgnoreIfExists bool `json:"ignoreIfExists,omitempty"`
+}
+
+// WorkspaceEdit 複数のドキュメントへの変更
+// DocumentChangesの要素は*TextDocumentEditもしくは*CreateFile
+type WorkspaceEdit struct {
+	DocumentChanges []interface{} `json:"documentChanges"`
+}
+
+// UnmarshalJSON DocumentChangesの要素を、kindの有無で*CreateFileと*TextDocumentEditに振り分ける
+func (e *WorkspaceEdit) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		DocumentChanges []json.RawMessage `json:"documentChanges"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	e.DocumentChanges = make([]interface{}, 0, len(raw.DocumentChanges))
+	for _, change := range raw.DocumentChanges {
+		var kind struct {
+			Kind string `json:"kind"`
+		}
+		if err := json.Unmarshal(change, &kind); err != nil {
+			return err
+		}
+		var dest interface{} = new(TextDocumentEdit)
+		if kind.Kind == "create" {
+			dest = new(CreateFile)
+		}
+		if err := json.Unmarshal(change, dest); err != nil {
+			return err
+		}
+		e.DocumentChanges = append(e.DocumentChanges, dest)
+	}
+	return nil
+}
+
+type InitializeParams struct {
+	Capabilities ClientCapabilities `json:"capabilities"`
+}
+
+// ClientCapabilities クライアントの機能のうち、このサーバーが参照するもの
+type ClientCapabilities struct {
+	TextDocument struct {
+		CodeAction struct {
+			ResolveSupport *struct {
+				Properties []string `json:"properties"`
+			} `json:"resolveSupport"`
+		} `json:"codeAction"`
+	} `json:"textDocument"`
+}
+
+type InitializeResult struct {
+	Capabilities ServerCapabilities `json:"capabilities"`
+	ServerInfo   *ServerInfo        `json:"serverInfo,omitempty"`
+}
+
+type ServerCapabilities struct {
+	// ドキュメントの同期方法(1: 変更の度に全文を送る)
+	TextDocumentSync   int                `json:"textDocumentSync"`
+	CodeActionProvider *CodeActionOptions `json:"codeActionProvider"`
+}
+
+type CodeActionOptions struct {
+	CodeActionKinds []string `json:"codeActionKinds"`
+	ResolveProvider bool     `json:"resolveProvider"`
+}
+
+type ServerInfo struct {
+	Name string `json:"name"`
+}
+
+// textDocumentSyncFull 変更の度に全文を送る同期方法
+const textDocumentSyncFull = 1
+
+type DidOpenTextDocumentParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+type DidChangeTextDocumentParams struct {
+	TextDocument struct {
+		URI     string `json:"uri"`
+		Version int    `json:"version"`
+	} `json:"textDocument"`
+	// 全文を送る同期方法のため、Rangeを持たない変更のみを扱う
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type CodeActionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Context      CodeActionContext      `json:"context"`
+}
+
+type CodeActionContext struct {
+	// 指定がある場合は、この種類(もしくはその下位の種類)のコードアクションのみを返す
+	Only []string `json:"only,omitempty"`
+}
+
+type CodeAction struct {
+	Title string         `json:"title"`
+	Kind  string         `json:"kind"`
+	Edit  *WorkspaceEdit `json:"edit,omitempty"`
+	// codeAction/resolveで編集内容を作成するための情報
+	Data *codeActionData `json:"data,omitempty"`
+}
+
+// codeActionData 編集内容を作成するテスト対象のメソッド
+type codeActionData struct {
+	URI string `json:"uri"`
+	// テスト対象のメソッド名
+	Method string `json:"method"`
+}
+
+type LogMessageParams struct {
+	// メッセージの種類(1: エラー)
+	Type    int    `json:"type"`
+	Message string `json:"message"`
+}
+
+// messageTypeError エラーのメッセージの種類
+const messageTypeError = 1
@@ -0,0 +1,343 @@
+// Package lsp エディタからテストコードを生成するための、最小限のLanguage Server Protocolのサーバー
+// メソッドの宣言に対して、tgenの解析結果から生成したテストを挿入(既にある場合は更新)するコードアクションを提供する
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/kazdevl/tgen"
+	"github.com/kazdevl/tgen/internal"
+)
+
+const (
+	// CodeActionTitle テストコードを生成するコードアクションの表示名
+	CodeActionTitle = "Generate tgen test"
+	// CodeActionKind テストコードを生成するコードアクションの種類
+	CodeActionKind = "source.tgen"
+)
+
+// Config テスト対象のファイルごとの、テストコードの生成方法
+type Config struct {
+	// テストの生成に利用するテンプレート(nilの場合はtgen.DefaultTemplatesを利用する)
+	Templates fs.FS
+	// テストケース名やエラーなどに利用する言語(ja, en)
+	Lang string
+	// 解析と生成のオプション
+	Options []tgen.Option
+	// 生成したテストファイルの内容の加工(mockのimportパスの置き換えなど)
+	Postprocess func([]byte) ([]byte, error)
+}
+
+// ConfigureFunc テスト対象のファイルパスから、テストコードの生成方法を決める
+type ConfigureFunc func(path string) (*Config, error)
+
+// Server テストコードを生成するコードアクションを提供するサーバー
+type Server struct {
+	configure ConfigureFunc
+	// エディタで開かれているドキュメントのURIと、その内容
+	documents map[string]*document
+	// クライアントがcodeAction/resolveで編集内容を補完できるか
+	resolveEdit bool
+}
+
+// document エディタで開かれているドキュメント
+type document struct {
+	version int
+	content []byte
+}
+
+// NewServer サーバーを作成する
+// configure: nilの場合は、全てのファイルで初期値の生成方法を利用する
+func NewServer(configure ConfigureFunc) *Server {
+	if configure == nil {
+		configure = func(string) (*Config, error) {
+			return new(Config), nil
+		}
+	}
+	return &Server{
+		configure: configure,
+		documents: map[string]*document{},
+	}
+}
+
+// Serve inからメッセージを読み込み、outにレスポンスを書き込む
+// exitの通知を受け取るか、inが終端に達するまで処理を続ける
+// 標準入出力の代わりにio.Pipeを渡すことで、同じプロセス内で動かすこともできる(NewClientを参照)
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	c := newConn(in, out)
+	for {
+		msg, err := c.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			var respErr *ResponseError
+			if !errors.As(err, &respErr) {
+				return err
+			}
+			null := json.RawMessage("null")
+			if err := c.reply(&null, nil, respErr); err != nil {
+				return err
+			}
+			continue
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		result, err := s.handle(ctx, msg)
+		if msg.isNotification() {
+			if err != nil {
+				// 通知にはレスポンスを返せないため、クライアントのログに出力する
+				if err := c.notify("window/logMessage", &LogMessageParams{Type: messageTypeError, Message: err.Error()}); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := c.reply(msg.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+// handle メソッドごとの処理を行う
+func (s *Server) handle(ctx context.Context, msg *message) (interface{}, error) {
+	switch msg.Method {
+	case "initialize":
+		var params InitializeParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		if resolveSupport := params.Capabilities.TextDocument.CodeAction.ResolveSupport; resolveSupport != nil {
+			for _, property := range resolveSupport.Properties {
+				s.resolveEdit = s.resolveEdit || property == "edit"
+			}
+		}
+		return &InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync: textDocumentSyncFull,
+				CodeActionProvider: &CodeActionOptions{
+					CodeActionKinds: []string{CodeActionKind},
+					ResolveProvider: true,
+				},
+			},
+			ServerInfo: &ServerInfo{Name: "tgen"},
+		}, nil
+	case "initialized", "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		s.documents[params.TextDocument.URI] = &document{
+			version: params.TextDocument.Version,
+			content: []byte(params.TextDocument.Text),
+		}
+		return nil, nil
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		s.documents[params.TextDocument.URI] = &document{
+			version: params.TextDocument.Version,
+			content: []byte(params.ContentChanges[len(params.ContentChanges)-1].Text),
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		delete(s.documents, params.TextDocument.URI)
+		return nil, nil
+	case "textDocument/codeAction":
+		var params CodeActionParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.codeActions(ctx, &params)
+	case "codeAction/resolve":
+		var action CodeAction
+		if err := unmarshalParams(msg, &action); err != nil {
+			return nil, err
+		}
+		if action.Data == nil {
+			return &action, nil
+		}
+		edit, err := s.generateEdit(ctx, action.Data)
+		if err != nil {
+			return nil, err
+		}
+		action.Edit = edit
+		return &action, nil
+	}
+	if msg.isNotification() {
+		// $/cancelRequestなど、対応していない通知は無視する
+		return nil, nil
+	}
+	return nil, &ResponseError{Code: codeMethodNotFound, Message: msg.Method}
+}
+
+func unmarshalParams(msg *message, dest interface{}) error {
+	if err := json.Unmarshal(msg.Params, dest); err != nil {
+		return &ResponseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// codeActions カーソルがメソッドの宣言にある場合に、テストコードを生成するコードアクションを返す
+// クライアントがcodeAction/resolveに対応している場合は、編集内容の作成(解析と生成)を選択されるまで遅らせる
+func (s *Server) codeActions(ctx context.Context, params *CodeActionParams) ([]*CodeAction, error) {
+	actions := make([]*CodeAction, 0, 1)
+	if !matchKind(params.Context.Only) {
+		return actions, nil
+	}
+	path, err := uriToPath(params.TextDocument.URI)
+	if err != nil || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+		return actions, nil
+	}
+	content, _, err := s.readDocument(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	method := findMethod(content, offsetAt(content, params.Range.Start))
+	if method == "" {
+		return actions, nil
+	}
+	action := &CodeAction{
+		Title: CodeActionTitle,
+		Kind:  CodeActionKind,
+		Data:  &codeActionData{URI: params.TextDocument.URI, Method: method},
+	}
+	if !s.resolveEdit {
+		edit, err := s.generateEdit(ctx, action.Data)
+		if err != nil {
+			return nil, err
+		}
+		action.Edit, action.Data = edit, nil
+	}
+	return append(actions, action), nil
+}
+
+// matchKind 要求されたコードアクションの種類に、テストコードを生成するコードアクションが含まれるか
+func matchKind(only []string) bool {
+	if len(only) == 0 {
+		return true
+	}
+	for _, kind := range only {
+		if kind == CodeActionKind || strings.HasPrefix(CodeActionKind, kind+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// findMethod ソースコードの指定した位置を含むメソッドの宣言を探し、そのメソッド名を返す
+// 編集中で構文が不正な場合も、解析できた範囲で探す
+func findMethod(content []byte, offset int) string {
+	fset := token.NewFileSet()
+	f, _ := parser.ParseFile(fset, "", content, parser.SkipObjectResolution)
+	if f == nil {
+		return ""
+	}
+	for _, decl := range f.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv == nil {
+			continue
+		}
+		if fset.Position(funcDecl.Pos()).Offset <= offset && offset <= fset.Position(funcDecl.End()).Offset {
+			return funcDecl.Name.Name
+		}
+	}
+	return ""
+}
+
+// generateEdit テスト対象のメソッドのテストコードを生成し、テストファイルへの変更を作成する
+// 既存のテストがある場合は置き換えるため、既存のテストファイルに関わらず生成する
+func (s *Server) generateEdit(ctx context.Context, data *codeActionData) (*WorkspaceEdit, error) {
+	path, err := uriToPath(data.URI)
+	if err != nil {
+		return nil, err
+	}
+	content, _, err := s.readDocument(data.URI)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := s.configure(path)
+	if err != nil {
+		return nil, err
+	}
+	opts := append([]tgen.Option{tgen.WithLang(cfg.Lang)}, cfg.Options...)
+	opts = append(opts,
+		tgen.WithOnly(regexp.MustCompile("^"+regexp.QuoteMeta(data.Method)+"$")),
+		tgen.WithRegenerate(true),
+	)
+	result, err := tgen.AnalyzeSource(ctx, path, content, opts...)
+	if err != nil {
+		return nil, err
+	}
+	generated, err := tgen.Generate(result, cfg.Templates)
+	if err != nil {
+		return nil, err
+	}
+	if generated == nil {
+		err := internal.NewError("error.test_not_generated", data.Method)
+		internal.SetErrorLang(err, cfg.Lang)
+		return nil, err
+	}
+	if cfg.Postprocess != nil {
+		if generated, err = cfg.Postprocess(generated); err != nil {
+			return nil, err
+		}
+	}
+
+	testURI := pathToURI(strings.TrimSuffix(path, ".go") + "_test.go")
+	testContent, version, err := s.readDocument(testURI)
+	if errors.Is(err, os.ErrNotExist) {
+		return newFileEdit(testURI, generated), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	edits, err := mergeTestFile(testContent, generated)
+	if err != nil {
+		return nil, err
+	}
+	return &WorkspaceEdit{
+		DocumentChanges: []interface{}{
+			&TextDocumentEdit{
+				TextDocument: OptionalVersionedTextDocumentIdentifier{URI: testURI, Version: version},
+				Edits:        edits,
+			},
+		},
+	}, nil
+}
+
+// readDocument エディタで開かれている場合はその内容とバージョンを、開かれていない場合はディスク上の内容を返す
+func (s *Server) readDocument(uri string) ([]byte, *int, error) {
+	if doc, ok := s.documents[uri]; ok {
+		version := doc.version
+		return doc.content, &version, nil
+	}
+	path, err := uriToPath(uri)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := os.ReadFile(path)
+	return content, nil, err
+}
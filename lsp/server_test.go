@@ -0,0 +1,240 @@
+package lsp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const existingTest = `package sample
+
+import "testing"
+
+func TestExisting(t *testing.T) {
+}
+`
+
+// TestServer_codeAction initialize・didOpen・textDocument/codeActionの順にメッセージを送り、
+// メソッドの宣言に対するコードアクションの編集内容を確認する
+func TestServer_codeAction(t *testing.T) {
+	path, err := filepath.Abs("testdata/sample/sample.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uri := pathToURI(path)
+	testURI := pathToURI(strings.TrimSuffix(path, ".go") + "_test.go")
+	methodLine := lineOf(t, content, "func (s *Service) Name")
+
+	tests := []struct {
+		name string
+		// クライアントがcodeAction/resolveで編集内容を補完できるか
+		resolveEdit bool
+		// エディタで開いている既存のテストファイルの内容(空文字の場合はテストファイルがない)
+		existingTest string
+		// コードアクションを要求する位置の行
+		line int
+		// コードアクションを返すか
+		wantAction bool
+	}{
+		{
+			name:       "new test file",
+			line:       methodLine,
+			wantAction: true,
+		},
+		{
+			name:         "merge into the existing test file",
+			existingTest: existingTest,
+			line:         methodLine,
+			wantAction:   true,
+		},
+		{
+			name:        "new test file with codeAction/resolve",
+			resolveEdit: true,
+			line:        methodLine,
+			wantAction:  true,
+		},
+		{
+			name:         "merge into the existing test file with codeAction/resolve",
+			resolveEdit:  true,
+			existingTest: existingTest,
+			line:         methodLine,
+			wantAction:   true,
+		},
+		{
+			name:       "outside of the method declarations",
+			line:       lineOf(t, content, "type Repository interface"),
+			wantAction: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(func(string) (*Config, error) {
+				return &Config{Lang: "en"}, nil
+			})
+			cl := NewClient(context.Background(), s)
+			defer func() {
+				if err := cl.Close(); err != nil {
+					t.Errorf("Close() error = %v", err)
+				}
+				if logMessages := cl.LogMessages(); len(logMessages) != 0 {
+					t.Errorf("LogMessages() = %v, want none", logMessages)
+				}
+			}()
+
+			var initializeResult InitializeResult
+			if err := cl.Call("initialize", initializeParams(tt.resolveEdit), &initializeResult); err != nil {
+				t.Fatalf("initialize error = %v", err)
+			}
+			if provider := initializeResult.Capabilities.CodeActionProvider; provider == nil || !provider.ResolveProvider {
+				t.Errorf("CodeActionProvider = %+v, want ResolveProvider", provider)
+			}
+			if err := cl.Notify("initialized", struct{}{}); err != nil {
+				t.Fatal(err)
+			}
+			open := func(uri, text string) {
+				t.Helper()
+				params := &DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: uri, LanguageID: "go", Version: 1, Text: text}}
+				if err := cl.Notify("textDocument/didOpen", params); err != nil {
+					t.Fatal(err)
+				}
+			}
+			open(uri, string(content))
+			if tt.existingTest != "" {
+				open(testURI, tt.existingTest)
+			}
+
+			var actions []*CodeAction
+			params := &CodeActionParams{
+				TextDocument: TextDocumentIdentifier{URI: uri},
+				Range:        Range{Start: Position{Line: tt.line}, End: Position{Line: tt.line}},
+				Context:      CodeActionContext{Only: []string{"source"}},
+			}
+			if err := cl.Call("textDocument/codeAction", params, &actions); err != nil {
+				t.Fatalf("textDocument/codeAction error = %v", err)
+			}
+			if !tt.wantAction {
+				if len(actions) != 0 {
+					t.Errorf("textDocument/codeAction = %d actions, want none", len(actions))
+				}
+				return
+			}
+			if len(actions) != 1 {
+				t.Fatalf("textDocument/codeAction = %d actions, want 1", len(actions))
+			}
+			action := actions[0]
+			if action.Title != CodeActionTitle || action.Kind != CodeActionKind {
+				t.Errorf("textDocument/codeAction = %q (%s), want %q (%s)", action.Title, action.Kind, CodeActionTitle, CodeActionKind)
+			}
+			if tt.resolveEdit {
+				// 編集内容はcodeAction/resolveで作成する
+				if action.Edit != nil || action.Data == nil {
+					t.Fatalf("textDocument/codeAction edit = %v, data = %v, want only data", action.Edit, action.Data)
+				}
+				var resolved CodeAction
+				if err := cl.Call("codeAction/resolve", action, &resolved); err != nil {
+					t.Fatalf("codeAction/resolve error = %v", err)
+				}
+				action = &resolved
+			}
+			if action.Edit == nil {
+				t.Fatal("code action has no edit")
+			}
+
+			if tt.existingTest == "" {
+				assertNewFileEdit(t, action.Edit, testURI)
+				return
+			}
+			assertMergeEdit(t, action.Edit, testURI, tt.existingTest)
+		})
+	}
+}
+
+// initializeParams initializeのパラメータ(resolveEdit: codeAction/resolveでeditを補完できることを伝えるか)
+func initializeParams(resolveEdit bool) interface{} {
+	properties := []string{}
+	if resolveEdit {
+		properties = append(properties, "edit")
+	}
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"codeAction": map[string]interface{}{
+					"resolveSupport": map[string]interface{}{"properties": properties},
+				},
+			},
+		},
+	}
+}
+
+// assertNewFileEdit テストファイルを作成し、生成したテストを書き込む編集内容か確認する
+func assertNewFileEdit(t *testing.T, edit *WorkspaceEdit, testURI string) {
+	t.Helper()
+	if len(edit.DocumentChanges) != 2 {
+		t.Fatalf("DocumentChanges = %d changes, want 2", len(edit.DocumentChanges))
+	}
+	createFile, ok := edit.DocumentChanges[0].(*CreateFile)
+	if !ok || createFile.URI != testURI {
+		t.Errorf("DocumentChanges[0] = %+v, want to create %s", edit.DocumentChanges[0], testURI)
+	}
+	docEdit, ok := edit.DocumentChanges[1].(*TextDocumentEdit)
+	if !ok || docEdit.TextDocument.URI != testURI || len(docEdit.Edits) != 1 {
+		t.Fatalf("DocumentChanges[1] = %+v, want one edit of %s", edit.DocumentChanges[1], testURI)
+	}
+	if got := docEdit.Edits[0].NewText; !strings.Contains(got, "func TestService_Name(t *testing.T) {") {
+		t.Errorf("new test file does not contain TestService_Name\n%s", got)
+	}
+}
+
+// assertMergeEdit エディタで開いている既存のテストファイルに、生成したテストを追加する編集内容か確認する
+func assertMergeEdit(t *testing.T, edit *WorkspaceEdit, testURI, existing string) {
+	t.Helper()
+	if len(edit.DocumentChanges) != 1 {
+		t.Fatalf("DocumentChanges = %d changes, want 1", len(edit.DocumentChanges))
+	}
+	docEdit, ok := edit.DocumentChanges[0].(*TextDocumentEdit)
+	if !ok || docEdit.TextDocument.URI != testURI {
+		t.Fatalf("DocumentChanges[0] = %+v, want an edit of %s", edit.DocumentChanges[0], testURI)
+	}
+	if version := docEdit.TextDocument.Version; version == nil || *version != 1 {
+		t.Errorf("TextDocument.Version = %v, want 1", version)
+	}
+	got := applyEdits(existing, docEdit.Edits)
+	for _, want := range []string{"func TestExisting(t *testing.T) {", "func TestService_Name(t *testing.T) {", "\"github.com/golang/mock/gomock\""} {
+		if !strings.Contains(got, want) {
+			t.Errorf("merged test file does not contain %s\n%s", want, got)
+		}
+	}
+}
+
+// applyEdits 編集内容をテキストに反映する(後ろの編集から反映して、前の編集の位置がずれないようにする)
+func applyEdits(text string, edits []TextEdit) string {
+	sorted := append([]TextEdit(nil), edits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return offsetAt([]byte(text), sorted[i].Range.Start) < offsetAt([]byte(text), sorted[j].Range.Start)
+	})
+	for i := len(sorted) - 1; i >= 0; i-- {
+		edit := sorted[i]
+		start, end := offsetAt([]byte(text), edit.Range.Start), offsetAt([]byte(text), edit.Range.End)
+		text = text[:start] + edit.NewText + text[end:]
+	}
+	return text
+}
+
+// lineOf 指定した文字列を含む最初の行(0始まり)
+func lineOf(t *testing.T, content []byte, substr string) int {
+	t.Helper()
+	for i, line := range strings.Split(string(content), "\n") {
+		if strings.Contains(line, substr) {
+			return i
+		}
+	}
+	t.Fatalf("%q is not found", substr)
+	return 0
+}
@@ -0,0 +1,17 @@
+package sample
+
+type Repository interface {
+	Find(id int) (string, error)
+}
+
+type Service struct {
+	Repo Repository
+}
+
+func (s *Service) Name(id int) (string, error) {
+	name, err := s.Repo.Find(id)
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
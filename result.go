@@ -0,0 +1,43 @@
+package tgen
+
+import "github.com/kazdevl/tgen/internal"
+
+// 解析結果とテンプレートのパラメータの型
+// テンプレートでは、TemplateParamsをjsonにした内容が.TemplateParamsとして参照される
+type (
+	// TestFile テスト対象のファイルから抽出した情報
+	TestFile = internal.TestFile
+	// TestCase テスト対象の関数ごとのテストケース
+	TestCase = internal.TestCase
+	// TemplateParams テンプレートのパラメータ
+	TemplateParams = internal.TemplateParams
+	// UpdateTestCase テンプレートのパラメータ用のテストケース
+	UpdateTestCase = internal.UpdateTestCase
+	// TemplateMockMethod テンプレートのパラメータ用のmock化するメソッド
+	TemplateMockMethod = internal.TemplateMockMethod
+	// FieldInfo 構造体のフィールドもしくはmock化する引数の情報
+	FieldInfo = internal.FieldInfo
+	// MethodInfo テスト対象の関数自体の情報
+	MethodInfo = internal.MethodInfo
+	// NondeterministicCall テスト対象の関数で呼び出している、実行ごとに結果が変わる関数
+	NondeterministicCall = internal.NondeterministicCall
+	// Instantiation 型パラメータを持つテスト対象の関数を、具体的な型で実体化した情報
+	Instantiation = internal.Instantiation
+	// Diagnostic 解析時に検出した、利用者に伝えるべき内容
+	Diagnostic = internal.Diagnostic
+)
+
+// Result テスト対象のファイルを解析した結果
+type Result struct {
+	// テスト対象のファイルのパス
+	Path string
+	// テスト対象のファイルから抽出した情報
+	TestFile *TestFile
+	// テンプレートのパラメータ
+	Params *TemplateParams
+
+	// AnalyzeSourceで解析した場合の、メモリ上のソースコードの内容
+	content []byte
+	// 解析時に指定されたオプション(テストコードの生成でも利用する)
+	opts *options
+}
@@ -0,0 +1,68 @@
+package golden
+
+//go:generate mockgen -source=$GOFILE -destination=mock_$GOFILE -package=$GOPACKAGE
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kazdevl/tgen/testdata/golden/repository"
+)
+
+var (
+	ErrSameName = errors.New("same name")
+	ErrReserved = errors.New("reserved name")
+)
+
+type Clock interface {
+	Now() time.Time
+}
+
+type UserService struct {
+	Repo  repository.UserRepository
+	Clock Clock
+	now   func() time.Time
+}
+
+func (s *UserService) Rename(ctx context.Context, id int, name string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	old, err := s.Repo.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if old == name {
+		return ErrSameName
+	}
+	return s.Repo.Save(ctx, id, name)
+}
+
+func (s *UserService) Name(ctx context.Context, id int) (string, error) {
+	name, err := s.Repo.Find(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if len(name) == 0 {
+		return "", errors.New("empty name")
+	}
+	if name == "admin" {
+		return "", ErrReserved
+	}
+	return name, nil
+}
+
+func CountNames(ctx context.Context, repo repository.UserRepository, ids []int) (int, error) {
+	count := 0
+	for _, id := range ids {
+		name, err := repo.Find(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		if name != "" {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,529 @@
+package golden
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kazdevl/tgen/testdata/golden/repository"
+)
+
+func TestUserService_Rename(t *testing.T) {
+	type fields struct {
+		Repo  func(ctrl *gomock.Controller) repository.UserRepository
+		Clock func(ctrl *gomock.Controller) Clock
+		now   func() time.Time
+	}
+	type args struct {
+		id   int
+		name string
+	}
+	errMock := errors.New("mock error")
+	tests := []struct {
+		name      string
+		fields    fields
+		args      args
+		wantErr   bool
+		wantErrIs error
+		cancelCtx bool
+	}{
+		{
+			name:      "error: context canceled",
+			cancelCtx: true,
+			fields: fields{
+				Clock: func(ctrl *gomock.Controller) Clock {
+					mock := NewMockClock(ctrl)
+					return mock
+				},
+				Repo: func(ctrl *gomock.Controller) repository.UserRepository {
+					mock := repository.NewMockUserRepository(ctrl)
+					return mock
+				},
+				// TODO embed expected return values
+				now: func() time.Time { return time.Time{} },
+			},
+			wantErr: true,
+		},
+		{
+			name: "Find returns error",
+			fields: fields{
+				Clock: func(ctrl *gomock.Controller) Clock {
+					mock := NewMockClock(ctrl)
+					return mock
+				},
+				Repo: func(ctrl *gomock.Controller) repository.UserRepository {
+					mock := repository.NewMockUserRepository(ctrl)
+					// TODO embed expected args and return values
+					mock.EXPECT().Find(gomock.Any(), 0).Return("", errMock)
+					return mock
+				},
+				// TODO embed expected return values
+				now: func() time.Time { return time.Time{} },
+			},
+			wantErr:   true,
+			wantErrIs: errMock,
+		},
+		{
+			name: "old == name",
+			// TODO make the mocks return values that satisfy old == name
+			fields: fields{
+				Clock: func(ctrl *gomock.Controller) Clock {
+					mock := NewMockClock(ctrl)
+					return mock
+				},
+				Repo: func(ctrl *gomock.Controller) repository.UserRepository {
+					mock := repository.NewMockUserRepository(ctrl)
+					// TODO embed expected args and return values
+					mock.EXPECT().Find(gomock.Any(), 0).Return("", nil)
+					return mock
+				},
+				// TODO embed expected return values
+				now: func() time.Time { return time.Time{} },
+			},
+			wantErr:   true,
+			wantErrIs: ErrSameName,
+		},
+		{
+			name: "success",
+			fields: fields{
+				Clock: func(ctrl *gomock.Controller) Clock {
+					mock := NewMockClock(ctrl)
+					return mock
+				},
+				Repo: func(ctrl *gomock.Controller) repository.UserRepository {
+					mock := repository.NewMockUserRepository(ctrl)
+					// TODO embed expected args and return values
+					mock.EXPECT().Find(gomock.Any(), 0).Return("", nil)
+					mock.EXPECT().Save(gomock.Any(), 0, "").Return(nil)
+					return mock
+				},
+				// TODO embed expected return values
+				now: func() time.Time { return time.Time{} },
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			ctx := context.Background()
+			if tt.cancelCtx {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				cancel()
+			}
+			s := &UserService{
+				Repo:  tt.fields.Repo(ctrl),
+				Clock: tt.fields.Clock(ctrl),
+				now:   tt.fields.now,
+			}
+			err := s.Rename(ctx, tt.args.id, tt.args.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserService.Rename(ctx, %v, %v) error = %v, wantErr %v", tt.args.id, tt.args.name, err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("UserService.Rename(ctx, %v, %v) error = %v, want %v", tt.args.id, tt.args.name, err, tt.wantErrIs)
+			}
+		})
+	}
+}
+
+func BenchmarkUserService_Rename(b *testing.B) {
+	type fields struct {
+		Repo  func(ctrl *gomock.Controller) repository.UserRepository
+		Clock func(ctrl *gomock.Controller) Clock
+		now   func() time.Time
+	}
+	type args struct {
+		id   int
+		name string
+	}
+	tt := struct {
+		fields fields
+		args   args
+	}{
+		fields: fields{
+			Clock: func(ctrl *gomock.Controller) Clock {
+				mock := NewMockClock(ctrl)
+				return mock
+			},
+			Repo: func(ctrl *gomock.Controller) repository.UserRepository {
+				mock := repository.NewMockUserRepository(ctrl)
+				// TODO embed expected args and return values
+				mock.EXPECT().Find(gomock.Any(), 0).Return("", nil).AnyTimes()
+				mock.EXPECT().Save(gomock.Any(), 0, "").Return(nil).AnyTimes()
+				return mock
+			},
+			now: func() time.Time { return time.Time{} },
+		},
+		// TODO set the args of the benchmark
+		args: args{},
+	}
+	ctrl := gomock.NewController(b)
+	defer ctrl.Finish()
+	ctx := context.Background()
+	s := &UserService{
+		Repo:  tt.fields.Repo(ctrl),
+		Clock: tt.fields.Clock(ctrl),
+		now:   tt.fields.now,
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Rename(ctx, tt.args.id, tt.args.name)
+	}
+}
+
+func FuzzUserService_Rename(f *testing.F) {
+	type fields struct {
+		Repo  func(ctrl *gomock.Controller) repository.UserRepository
+		Clock func(ctrl *gomock.Controller) Clock
+		now   func() time.Time
+	}
+	type args struct {
+		id   int
+		name string
+	}
+	// TODO add seeds that reach each branch to the seed corpus
+	f.Add(0, "")
+	f.Fuzz(func(t *testing.T, id int, name string) {
+		tt := struct {
+			fields fields
+			args   args
+		}{
+			fields: fields{
+				Clock: func(ctrl *gomock.Controller) Clock {
+					mock := NewMockClock(ctrl)
+					return mock
+				},
+				Repo: func(ctrl *gomock.Controller) repository.UserRepository {
+					mock := repository.NewMockUserRepository(ctrl)
+					// TODO embed expected return values
+					mock.EXPECT().Find(gomock.Any(), gomock.Any()).Return("", nil).AnyTimes()
+					mock.EXPECT().Save(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+					return mock
+				},
+				now: func() time.Time { return time.Time{} },
+			},
+			args: args{
+				id:   id,
+				name: name,
+			},
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		ctx := context.Background()
+		s := &UserService{
+			Repo:  tt.fields.Repo(ctrl),
+			Clock: tt.fields.Clock(ctrl),
+			now:   tt.fields.now,
+		}
+		// TODO check the invariants of the results (a panic fails the fuzz test)
+		s.Rename(ctx, tt.args.id, tt.args.name)
+	})
+}
+
+func TestUserService_Name(t *testing.T) {
+	type fields struct {
+		Repo  func(ctrl *gomock.Controller) repository.UserRepository
+		Clock func(ctrl *gomock.Controller) Clock
+		now   func() time.Time
+	}
+	type args struct {
+		id int
+	}
+	errMock := errors.New("mock error")
+	tests := []struct {
+		name       string
+		fields     fields
+		args       args
+		want       string
+		wantErr    bool
+		wantErrIs  error
+		wantErrMsg string
+	}{
+		{
+			name: "Find returns error",
+			fields: fields{
+				Clock: func(ctrl *gomock.Controller) Clock {
+					mock := NewMockClock(ctrl)
+					return mock
+				},
+				Repo: func(ctrl *gomock.Controller) repository.UserRepository {
+					mock := repository.NewMockUserRepository(ctrl)
+					// TODO embed expected args and return values
+					mock.EXPECT().Find(gomock.Any(), 0).Return("", errMock)
+					return mock
+				},
+				// TODO embed expected return values
+				now: func() time.Time { return time.Time{} },
+			},
+			wantErr:   true,
+			wantErrIs: errMock,
+		},
+		{
+			name: "len(name) == 0",
+			fields: fields{
+				Clock: func(ctrl *gomock.Controller) Clock {
+					mock := NewMockClock(ctrl)
+					return mock
+				},
+				Repo: func(ctrl *gomock.Controller) repository.UserRepository {
+					mock := repository.NewMockUserRepository(ctrl)
+					// TODO embed expected args and return values
+					mock.EXPECT().Find(gomock.Any(), 0).Return("", nil)
+					return mock
+				},
+				// TODO embed expected return values
+				now: func() time.Time { return time.Time{} },
+			},
+			wantErr:    true,
+			wantErrMsg: "empty name",
+		},
+		{
+			name: "name == \"admin\"",
+			fields: fields{
+				Clock: func(ctrl *gomock.Controller) Clock {
+					mock := NewMockClock(ctrl)
+					return mock
+				},
+				Repo: func(ctrl *gomock.Controller) repository.UserRepository {
+					mock := repository.NewMockUserRepository(ctrl)
+					// TODO embed expected args and return values
+					mock.EXPECT().Find(gomock.Any(), 0).Return("admin", nil)
+					return mock
+				},
+				// TODO embed expected return values
+				now: func() time.Time { return time.Time{} },
+			},
+			wantErr:   true,
+			wantErrIs: ErrReserved,
+		},
+		{
+			name: "success",
+			fields: fields{
+				Clock: func(ctrl *gomock.Controller) Clock {
+					mock := NewMockClock(ctrl)
+					return mock
+				},
+				Repo: func(ctrl *gomock.Controller) repository.UserRepository {
+					mock := repository.NewMockUserRepository(ctrl)
+					// TODO embed expected args and return values
+					mock.EXPECT().Find(gomock.Any(), 0).Return("admina", nil)
+					return mock
+				},
+				// TODO embed expected return values
+				now: func() time.Time { return time.Time{} },
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			ctx := context.Background()
+			s := &UserService{
+				Repo:  tt.fields.Repo(ctrl),
+				Clock: tt.fields.Clock(ctrl),
+				now:   tt.fields.now,
+			}
+			got, err := s.Name(ctx, tt.args.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserService.Name(ctx, %v) error = %v, wantErr %v", tt.args.id, err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("UserService.Name(ctx, %v) error = %v, want %v", tt.args.id, err, tt.wantErrIs)
+				return
+			}
+			if tt.wantErrMsg != "" && (err == nil || err.Error() != tt.wantErrMsg) {
+				t.Errorf("UserService.Name(ctx, %v) error = %v, want %q", tt.args.id, err, tt.wantErrMsg)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UserService.Name(ctx, %v) got = %v, want %v", tt.args.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkUserService_Name(b *testing.B) {
+	type fields struct {
+		Repo  func(ctrl *gomock.Controller) repository.UserRepository
+		Clock func(ctrl *gomock.Controller) Clock
+		now   func() time.Time
+	}
+	type args struct {
+		id int
+	}
+	tt := struct {
+		fields fields
+		args   args
+	}{
+		fields: fields{
+			Clock: func(ctrl *gomock.Controller) Clock {
+				mock := NewMockClock(ctrl)
+				return mock
+			},
+			Repo: func(ctrl *gomock.Controller) repository.UserRepository {
+				mock := repository.NewMockUserRepository(ctrl)
+				// TODO embed expected args and return values
+				mock.EXPECT().Find(gomock.Any(), 0).Return("admina", nil).AnyTimes()
+				return mock
+			},
+			now: func() time.Time { return time.Time{} },
+		},
+		// TODO set the args of the benchmark
+		args: args{},
+	}
+	ctrl := gomock.NewController(b)
+	defer ctrl.Finish()
+	ctx := context.Background()
+	s := &UserService{
+		Repo:  tt.fields.Repo(ctrl),
+		Clock: tt.fields.Clock(ctrl),
+		now:   tt.fields.now,
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Name(ctx, tt.args.id)
+	}
+}
+
+func FuzzUserService_Name(f *testing.F) {
+	type fields struct {
+		Repo  func(ctrl *gomock.Controller) repository.UserRepository
+		Clock func(ctrl *gomock.Controller) Clock
+		now   func() time.Time
+	}
+	type args struct {
+		id int
+	}
+	// TODO add seeds that reach each branch to the seed corpus
+	f.Add(0)
+	f.Fuzz(func(t *testing.T, id int) {
+		tt := struct {
+			fields fields
+			args   args
+		}{
+			fields: fields{
+				Clock: func(ctrl *gomock.Controller) Clock {
+					mock := NewMockClock(ctrl)
+					return mock
+				},
+				Repo: func(ctrl *gomock.Controller) repository.UserRepository {
+					mock := repository.NewMockUserRepository(ctrl)
+					// TODO embed expected return values
+					mock.EXPECT().Find(gomock.Any(), gomock.Any()).Return("admina", nil).AnyTimes()
+					return mock
+				},
+				now: func() time.Time { return time.Time{} },
+			},
+			args: args{
+				id: id,
+			},
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		ctx := context.Background()
+		s := &UserService{
+			Repo:  tt.fields.Repo(ctrl),
+			Clock: tt.fields.Clock(ctrl),
+			now:   tt.fields.now,
+		}
+		// TODO check the invariants of the results (a panic fails the fuzz test)
+		s.Name(ctx, tt.args.id)
+	})
+}
+
+func TestCountNames(t *testing.T) {
+	type args struct {
+		repo func(ctrl *gomock.Controller) repository.UserRepository
+		ids  []int
+	}
+	errMock := errors.New("mock error")
+	tests := []struct {
+		name      string
+		args      args
+		want      int
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "Find returns error",
+			args: args{
+				repo: func(ctrl *gomock.Controller) repository.UserRepository {
+					mock := repository.NewMockUserRepository(ctrl)
+					// TODO embed expected args and return values
+					mock.EXPECT().Find(gomock.Any(), 0).Return("", errMock)
+					return mock
+				},
+			},
+			wantErr:   true,
+			wantErrIs: errMock,
+		},
+		{
+			name: "success",
+			args: args{
+				repo: func(ctrl *gomock.Controller) repository.UserRepository {
+					mock := repository.NewMockUserRepository(ctrl)
+					// TODO embed expected args and return values
+					mock.EXPECT().Find(gomock.Any(), 0).Return("", nil)
+					return mock
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			ctx := context.Background()
+			got, err := CountNames(ctx, tt.args.repo(ctrl), tt.args.ids)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CountNames(ctx, repo, %v) error = %v, wantErr %v", tt.args.ids, err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("CountNames(ctx, repo, %v) error = %v, want %v", tt.args.ids, err, tt.wantErrIs)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CountNames(ctx, repo, %v) got = %v, want %v", tt.args.ids, got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkCountNames(b *testing.B) {
+	type args struct {
+		repo func(ctrl *gomock.Controller) repository.UserRepository
+		ids  []int
+	}
+	tt := struct {
+		args args
+	}{
+		// TODO set the args of the benchmark
+		args: args{
+			repo: func(ctrl *gomock.Controller) repository.UserRepository {
+				mock := repository.NewMockUserRepository(ctrl)
+				// TODO embed expected args and return values
+				mock.EXPECT().Find(gomock.Any(), 0).Return("", nil).AnyTimes()
+				return mock
+			},
+		},
+	}
+	ctrl := gomock.NewController(b)
+	defer ctrl.Finish()
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		CountNames(ctx, tt.args.repo(ctrl), tt.args.ids)
+	}
+}
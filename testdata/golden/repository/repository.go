@@ -0,0 +1,10 @@
+package repository
+
+//go:generate mockgen -source=$GOFILE -destination=mock_$GOFILE -package=$GOPACKAGE
+
+import "context"
+
+type UserRepository interface {
+	Find(ctx context.Context, id int) (string, error)
+	Save(ctx context.Context, id int, name string) error
+}
@@ -0,0 +1,35 @@
+package golden
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type UserStore struct {
+	DB *sql.DB
+}
+
+func (s *UserStore) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := s.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// ErrUserNotFound ユーザーが存在しない場合のエラー
+var ErrUserNotFound = errors.New("user not found")
+
+func (s *UserStore) Find(ctx context.Context, id int) (string, int, error) {
+	var name string
+	var age int
+	err := s.DB.QueryRowContext(ctx, "SELECT name, age FROM users WHERE id = ?", id).Scan(&name, &age)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", 0, ErrUserNotFound
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	return name, age, nil
+}
@@ -0,0 +1,277 @@
+package golden
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestUserStore_Count(t *testing.T) {
+	type fields struct {
+		DB func(t testing.TB) *sql.DB
+	}
+	type args struct {
+	}
+	errMock := errors.New("mock error")
+	tests := []struct {
+		name      string
+		fields    fields
+		args      args
+		want      int
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "QueryRowContext returns error",
+			fields: fields{
+				DB: func(t testing.TB) *sql.DB {
+					db, mock, err := sqlmock.New()
+					if err != nil {
+						t.Fatal(err)
+					}
+					t.Cleanup(func() {
+						if err := mock.ExpectationsWereMet(); err != nil {
+							t.Errorf("there were unfulfilled expectations: %s", err)
+						}
+						db.Close()
+					})
+					// TODO embed expected args and rows
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM users")).WillReturnError(errMock)
+					return db
+				},
+			},
+			wantErr:   true,
+			wantErrIs: errMock,
+		},
+		{
+			name: "success",
+			fields: fields{
+				DB: func(t testing.TB) *sql.DB {
+					db, mock, err := sqlmock.New()
+					if err != nil {
+						t.Fatal(err)
+					}
+					t.Cleanup(func() {
+						if err := mock.ExpectationsWereMet(); err != nil {
+							t.Errorf("there were unfulfilled expectations: %s", err)
+						}
+						db.Close()
+					})
+					// TODO embed expected args and rows
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM users")).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+					return db
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := &UserStore{
+				DB: tt.fields.DB(t),
+			}
+			got, err := s.Count(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserStore.Count(ctx) error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("UserStore.Count(ctx) error = %v, want %v", err, tt.wantErrIs)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UserStore.Count(ctx) got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkUserStore_Count(b *testing.B) {
+	type fields struct {
+		DB func(t testing.TB) *sql.DB
+	}
+	type args struct {
+	}
+	tt := struct {
+		fields fields
+		args   args
+	}{
+		fields: fields{
+			DB: func(t testing.TB) *sql.DB {
+				db, mock, err := sqlmock.New()
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { db.Close() })
+				// TODO embed expected args and rows
+				// sqlmock matches each expectation only once, so expect the calls for every iteration
+				for i := 0; i < b.N; i++ {
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM users")).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+				}
+				return db
+			},
+		},
+		args: args{},
+	}
+	ctx := context.Background()
+	s := &UserStore{
+		DB: tt.fields.DB(b),
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Count(ctx)
+	}
+}
+
+func TestUserStore_Find(t *testing.T) {
+	type fields struct {
+		DB func(t testing.TB) *sql.DB
+	}
+	type args struct {
+		id int
+	}
+	errMock := errors.New("mock error")
+	tests := []struct {
+		name      string
+		fields    fields
+		args      args
+		want      string
+		want1     int
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "errors.Is is true",
+			fields: fields{
+				DB: func(t testing.TB) *sql.DB {
+					db, mock, err := sqlmock.New()
+					if err != nil {
+						t.Fatal(err)
+					}
+					t.Cleanup(func() {
+						if err := mock.ExpectationsWereMet(); err != nil {
+							t.Errorf("there were unfulfilled expectations: %s", err)
+						}
+						db.Close()
+					})
+					// TODO embed expected args and rows
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT name, age FROM users WHERE id = ?")).WillReturnRows(sqlmock.NewRows(nil))
+					return db
+				},
+			},
+			wantErr:   true,
+			wantErrIs: ErrUserNotFound,
+		},
+		{
+			name: "QueryRowContext returns error",
+			fields: fields{
+				DB: func(t testing.TB) *sql.DB {
+					db, mock, err := sqlmock.New()
+					if err != nil {
+						t.Fatal(err)
+					}
+					t.Cleanup(func() {
+						if err := mock.ExpectationsWereMet(); err != nil {
+							t.Errorf("there were unfulfilled expectations: %s", err)
+						}
+						db.Close()
+					})
+					// TODO embed expected args and rows
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT name, age FROM users WHERE id = ?")).WillReturnError(errMock)
+					return db
+				},
+			},
+			wantErr:   true,
+			wantErrIs: errMock,
+		},
+		{
+			name: "success",
+			fields: fields{
+				DB: func(t testing.TB) *sql.DB {
+					db, mock, err := sqlmock.New()
+					if err != nil {
+						t.Fatal(err)
+					}
+					t.Cleanup(func() {
+						if err := mock.ExpectationsWereMet(); err != nil {
+							t.Errorf("there were unfulfilled expectations: %s", err)
+						}
+						db.Close()
+					})
+					// TODO embed expected args and rows
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT name, age FROM users WHERE id = ?")).WillReturnRows(sqlmock.NewRows([]string{"name", "age"}).AddRow("", 0))
+					return db
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := &UserStore{
+				DB: tt.fields.DB(t),
+			}
+			got, got1, err := s.Find(ctx, tt.args.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserStore.Find(ctx, %v) error = %v, wantErr %v", tt.args.id, err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("UserStore.Find(ctx, %v) error = %v, want %v", tt.args.id, err, tt.wantErrIs)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UserStore.Find(ctx, %v) got = %v, want %v", tt.args.id, got, tt.want)
+			}
+			if !reflect.DeepEqual(got1, tt.want1) {
+				t.Errorf("UserStore.Find(ctx, %v) got1 = %v, want %v", tt.args.id, got1, tt.want1)
+			}
+		})
+	}
+}
+
+func BenchmarkUserStore_Find(b *testing.B) {
+	type fields struct {
+		DB func(t testing.TB) *sql.DB
+	}
+	type args struct {
+		id int
+	}
+	tt := struct {
+		fields fields
+		args   args
+	}{
+		fields: fields{
+			DB: func(t testing.TB) *sql.DB {
+				db, mock, err := sqlmock.New()
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { db.Close() })
+				// TODO embed expected args and rows
+				// sqlmock matches each expectation only once, so expect the calls for every iteration
+				for i := 0; i < b.N; i++ {
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT name, age FROM users WHERE id = ?")).WillReturnRows(sqlmock.NewRows([]string{"name", "age"}).AddRow("", 0))
+				}
+				return db
+			},
+		},
+		// TODO set the args of the benchmark
+		args: args{},
+	}
+	ctx := context.Background()
+	s := &UserStore{
+		DB: tt.fields.DB(b),
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Find(ctx, tt.args.id)
+	}
+}
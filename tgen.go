@@ -1,35 +1,308 @@
 package tgen
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
-	"go/parser"
-	"go/token"
+	"go/ast"
+	"path/filepath"
+	"regexp"
 
 	"github.com/kazdevl/tgen/internal"
 	"golang.org/x/tools/go/packages"
 )
 
+// Option テンプレートのパラメータの作成方法を変更するオプション
+type Option func(*options)
+
+type options struct {
+	// 型パラメータ名と、テストで利用する具体的な型の組み合わせ
+	typeArgs map[string]string
+	// テストケース名やエラーなどに利用する言語(ja, en)
+	lang string
+	// テストの結果の比較に利用するライブラリ
+	assertion string
+	// テスト関数に加えて、ベンチマーク関数を生成するか
+	bench bool
+	// テスト関数に加えて、fuzzテスト関数を生成するか
+	fuzz bool
+	// 以下はテストコードの生成(Generate)で利用する
+	// 指定した正規表現に合致する関数もしくはメソッドに対してテストを生成する
+	only *regexp.Regexp
+	// 指定した正規表現に合致しない関数もしくはメソッドに対してテストを生成する
+	excl *regexp.Regexp
+	// 公開されている関数もしくはメソッドに対してテストを生成する
+	exported bool
+	// エラーメッセージにテストの入力を出力するか
+	printInputs bool
+	// サブテストを並行実行するテストコードを出力するか
+	parallel bool
+	// 既存のテストファイルに同じテストがある場合も生成するか
+	regenerate bool
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		printInputs: true,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithTypeArgs 型パラメータを持つ構造体や関数のテストで利用する、具体的な型を指定する
+// 指定がない型パラメータは、制約を満たす型が選択される
+func WithTypeArgs(typeArgs map[string]string) Option {
+	return func(o *options) {
+		o.typeArgs = typeArgs
+	}
+}
+
+// WithLang テストケース名やエラーなどに利用する言語(ja, en)を指定する
+// 指定がない場合や対応していない言語の場合は、日本語が利用される
+func WithLang(lang string) Option {
+	return func(o *options) {
+		o.lang = lang
+	}
+}
+
+// テストの結果の比較に利用するライブラリ(WithAssertionで指定する)
+const (
+	// AssertionAssert testify/assert(初期値)
+	AssertionAssert = internal.AssertionAssert
+	// AssertionRequire testify/require
+	AssertionRequire = internal.AssertionRequire
+	// AssertionCmp github.com/google/go-cmp
+	AssertionCmp = internal.AssertionCmp
+	// AssertionStd 標準ライブラリのみ(reflect.DeepEqual・errors.Is)
+	AssertionStd = internal.AssertionStd
+)
+
+// WithAssertion テストの結果の比較に利用するライブラリを指定する
+// 指定がない場合や対応していないライブラリの場合は、testify/assertが利用される
+func WithAssertion(assertion string) Option {
+	return func(o *options) {
+		o.assertion = assertion
+	}
+}
+
+// WithBench テスト関数に加えて、正常系のmockを利用したベンチマーク関数を生成するかを指定する
+func WithBench(bench bool) Option {
+	return func(o *options) {
+		o.bench = bench
+	}
+}
+
+// WithFuzz テスト関数に加えて、引数の値を生成するfuzzテスト関数を生成するかを指定する
+// context.Contextとmock化する引数以外の引数が、全て文字列・[]byte・数値・真偽値の関数が対象になる
+func WithFuzz(fuzz bool) Option {
+	return func(o *options) {
+		o.fuzz = fuzz
+	}
+}
+
+// WithOnly 指定した正規表現に合致する関数もしくはメソッドに対してテストを生成する
+func WithOnly(only *regexp.Regexp) Option {
+	return func(o *options) {
+		o.only = only
+	}
+}
+
+// WithExcl 指定した正規表現に合致しない関数もしくはメソッドに対してテストを生成する
+// WithOnlyとWithExportedよりも優先される
+func WithExcl(excl *regexp.Regexp) Option {
+	return func(o *options) {
+		o.excl = excl
+	}
+}
+
+// WithExported 公開されている関数もしくはメソッドに対してテストを生成する
+// WithOnlyよりも優先される
+func WithExported(exported bool) Option {
+	return func(o *options) {
+		o.exported = exported
+	}
+}
+
+// WithPrintInputs エラーメッセージにテストの入力を出力するかを指定する(初期値はtrue)
+func WithPrintInputs(printInputs bool) Option {
+	return func(o *options) {
+		o.printInputs = printInputs
+	}
+}
+
+// WithParallel サブテストを並行実行するテストコードを出力するかを指定する
+func WithParallel(parallel bool) Option {
+	return func(o *options) {
+		o.parallel = parallel
+	}
+}
+
+// WithRegenerate 既存のテストファイルに同じテストがある場合も、テストコードを生成するかを指定する
+// 生成した内容で既存のテストを置き換える場合(エディタからの生成など)に利用する
+func WithRegenerate(regenerate bool) Option {
+	return func(o *options) {
+		o.regenerate = regenerate
+	}
+}
+
 // CreateParameterWithFilePath ファイルパスを使って、テンプレートのパラメータを作成する
-func CreateParameterWithFilePath(src string) ([]byte, error) {
-	fset := token.NewFileSet()
-	f, err := parser.ParseFile(fset, src, nil, 0)
+// gotestsの-template_params_fileに渡すjsonを返す
+func CreateParameterWithFilePath(src string, opts ...Option) ([]byte, error) {
+	result, err := Analyze(context.Background(), src, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result.Params)
+}
+
+// Analyze テスト対象のファイルを解析し、テンプレートのパラメータを作成する
+func Analyze(ctx context.Context, src string, opts ...Option) (*Result, error) {
+	return analyze(ctx, src, nil, newOptions(opts))
+}
+
+// AnalyzeSource メモリ上のソースコード(エディタで保存されていない内容など)を解析し、テンプレートのパラメータを作成する
+// path: ソースコードのファイルパス(ディスク上に存在しなくてもよい)
+// content: ソースコードの内容(ディスク上の内容の代わりに利用される)
+func AnalyzeSource(ctx context.Context, path string, content []byte, opts ...Option) (*Result, error) {
+	return analyze(ctx, path, content, newOptions(opts))
+}
+
+// AnalyzeFiles 複数のテスト対象のファイルを解析する
+// 同じディレクトリのファイルはパッケージを一度だけ読み込むため、ファイルごとにAnalyzeを呼び出すよりも速い
+// 戻り値はpathsと同じ順番で、解析に失敗したファイルはResultがnilになり、同じ位置のerrorに原因が入る
+func AnalyzeFiles(ctx context.Context, paths []string, opts ...Option) ([]*Result, []error) {
+	o := newOptions(opts)
+	results := make([]*Result, len(paths))
+	errs := make([]error, len(paths))
+	dirs := make([]string, 0)
+	dirIndexes := map[string][]int{}
+	for i, path := range paths {
+		dir := filepath.Dir(path)
+		if _, ok := dirIndexes[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		dirIndexes[dir] = append(dirIndexes[dir], i)
+	}
+	for _, dir := range dirs {
+		indexes := dirIndexes[dir]
+		srcs := make([]string, 0, len(indexes))
+		for _, i := range indexes {
+			srcs = append(srcs, paths[i])
+		}
+		pkg, err := loadPackage(ctx, srcs, nil)
+		if err != nil && len(srcs) > 1 {
+			// 同じディレクトリに異なるパッケージのファイルが含まれる場合(ビルドタグなど)は、ファイルごとに読み込む
+			for _, i := range indexes {
+				results[i], errs[i] = analyze(ctx, paths[i], nil, o)
+			}
+			continue
+		}
+		for _, i := range indexes {
+			if err != nil {
+				errs[i] = err
+				internal.SetErrorLang(err, o.lang)
+				continue
+			}
+			results[i], errs[i] = newResult(pkg, paths[i], nil, o)
+		}
+	}
+	return results, errs
+}
+
+func analyze(ctx context.Context, src string, content []byte, o *options) (*Result, error) {
+	var overlay map[string][]byte
+	if content != nil {
+		absSrc, err := filepath.Abs(src)
+		if err != nil {
+			return nil, err
+		}
+		overlay = map[string][]byte{absSrc: content}
+	}
+	pkg, err := loadPackage(ctx, []string{src}, overlay)
 	if err != nil {
+		internal.SetErrorLang(err, o.lang)
 		return nil, err
 	}
+	return newResult(pkg, src, content, o)
+}
+
+// newResult 読み込んだパッケージから、テスト対象のファイルを解析した結果を作成する
+func newResult(pkg *packages.Package, src string, content []byte, o *options) (*Result, error) {
+	testFile, params, err := analyzeFile(pkg, src, o)
+	if err != nil {
+		internal.SetErrorLang(err, o.lang)
+		return nil, err
+	}
+	return &Result{
+		Path:     src,
+		TestFile: testFile,
+		Params:   params,
+		content:  content,
+		opts:     o,
+	}, nil
+}
+
+// loadPackage テスト対象のファイルを型情報付きで読み込む
+// 複数のファイルを指定した場合は、一つのパッケージとして読み込む
+// overlayはpackages.Config.Overlayとして、ディスク上の内容の代わりに利用する(キーは絶対パス)
+func loadPackage(ctx context.Context, srcs []string, overlay map[string][]byte) (*packages.Package, error) {
 	cfg := &packages.Config{
-		Mode: packages.NeedImports | packages.NeedTypes,
+		Context: ctx,
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports |
+			packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo,
+		Overlay: overlay,
+	}
+	if overlay != nil {
+		absSrcs := make([]string, 0, len(srcs))
+		for _, src := range srcs {
+			absSrc, err := filepath.Abs(src)
+			if err != nil {
+				return nil, err
+			}
+			absSrcs = append(absSrcs, absSrc)
+		}
+		srcs = absSrcs
 	}
-	pkgs, err := packages.Load(cfg, src)
+	pkgs, err := packages.Load(cfg, srcs...)
 	if err != nil {
 		return nil, err
 	}
 	if len(pkgs) != 1 {
-		return nil, errors.New("pkgsの中身は一つを想定しています")
+		return nil, internal.NewError("error.pkgs_not_one")
 	}
-	base, err := internal.GetAnalysisResult(f, fset, pkgs[0].Types)
+	return pkgs[0], nil
+}
+
+// analyzeFile 読み込んだパッケージの構文木と型情報を使って、テスト対象のファイルを解析する
+// 構文木は読み込んだパッケージのものを利用するため、ファイルのパースは一度のみ行われる
+func analyzeFile(pkg *packages.Package, src string, o *options) (*internal.TestFile, *internal.TemplateParams, error) {
+	f, err := findSyntax(pkg, src)
+	if err != nil {
+		return nil, nil, err
+	}
+	base, err := internal.GetAnalysisResult(f, pkg.Fset, pkg.Types, pkg.TypesInfo, o.typeArgs)
+	if err != nil {
+		return nil, nil, err
+	}
+	params := internal.CreateTemplateParams(base, o.lang)
+	params.Assertion = internal.NormalizeAssertion(o.assertion)
+	params.Bench = o.bench
+	params.Fuzz = o.fuzz
+	return base, params, nil
+}
+
+// findSyntax 読み込んだパッケージの構文木から、対象ファイルのものを探す
+// 型情報(types.Info)はパッケージが保持する構文木のノードに紐づくため、別途パースしたものは利用できない
+func findSyntax(pkg *packages.Package, src string) (*ast.File, error) {
+	absSrc, err := filepath.Abs(src)
 	if err != nil {
 		return nil, err
 	}
-	return json.Marshal(internal.CreateTemplateParams(base))
+	for _, f := range pkg.Syntax {
+		if pkg.Fset.Position(f.Pos()).Filename == absSrc {
+			return f, nil
+		}
+	}
+	return nil, internal.NewError("error.syntax_not_found")
 }
@@ -0,0 +1,89 @@
+package tgen
+
+import (
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+
+	"github.com/kazdevl/tgen/internal"
+)
+
+// UpdateResult 生成したテストコードを、既存のテストファイルに反映した結果
+type UpdateResult struct {
+	// 反映した後のテストファイルの内容
+	Content []byte
+	// 追加したテスト関数名
+	AddedFuncs []string
+	// テスト関数名ごとの、追加したテストケース名
+	AddedCases map[string][]string
+	// テスト関数名ごとの、解析結果から生成したテストケース名(既存のテストファイルにあるものも含む)
+	GeneratedCases map[string][]string
+}
+
+// Update 解析結果からテストコードを生成し、既存のテストファイルの内容に反映する
+// 既存のテスト関数は利用者が編集している前提で置き換えず、名前が一致するテストケースがないもののみをTODOコメント付きで追加する
+// 既存のテストファイルにないテスト関数とimportは追加する
+// existing: 既存のテストファイルの内容(nilの場合は、生成したテストコードをそのまま返す)
+// opts: 解析時のオプションに加えて、生成に利用するオプション(WithOnlyなど)
+func Update(result *Result, templates fs.FS, existing []byte, opts ...Option) (*UpdateResult, error) {
+	if result == nil {
+		return nil, internal.NewError("error.result_nil")
+	}
+	o := new(options)
+	if result.opts != nil {
+		*o = *result.opts
+	} else {
+		o = newOptions(nil)
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	// 既存のテスト関数にテストケースを追加するため、既存のテストファイルに関わらず生成する
+	o.regenerate = true
+	r := *result
+	r.opts = o
+	generated, err := Generate(&r, templates)
+	if err != nil {
+		return nil, err
+	}
+	if generated == nil {
+		return &UpdateResult{Content: existing, AddedCases: map[string][]string{}, GeneratedCases: map[string][]string{}}, nil
+	}
+	funcNames, generatedCases := testCases(generated)
+	if existing == nil {
+		return &UpdateResult{Content: generated, AddedFuncs: funcNames, AddedCases: map[string][]string{}, GeneratedCases: generatedCases}, nil
+	}
+	edits, summary, err := internal.MergeTestFile(existing, generated, internal.MergeAddCases, o.lang)
+	if err != nil {
+		return nil, err
+	}
+	content := internal.ApplyTextEdits(existing, edits)
+	if formatted, err := format.Source(content); err == nil {
+		content = formatted
+	}
+	return &UpdateResult{
+		Content:        content,
+		AddedFuncs:     summary.AddedFuncs,
+		AddedCases:     summary.AddedCases,
+		GeneratedCases: generatedCases,
+	}, nil
+}
+
+// testCases テストコードに含まれる関数名の一覧と、関数名ごとのテストケース名
+func testCases(src []byte) ([]string, map[string][]string) {
+	cases := map[string][]string{}
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, cases
+	}
+	names := make([]string, 0, len(f.Decls))
+	for _, decl := range f.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok {
+			names = append(names, funcDecl.Name.Name)
+			cases[funcDecl.Name.Name] = internal.TestCaseNames(funcDecl)
+		}
+	}
+	return names, cases
+}